@@ -2,18 +2,30 @@ package main
 
 import (
 	"fmt"
+	"sync"
 )
 
+// Environments can be shared by spawned goroutines, so every access to values
+// holds mu.
 type Environment struct {
-	values    map[string]any
+	mu        sync.RWMutex
+	values    map[string]Value
 	enclosing *Environment
 	id        int
+	generator *generatorBody
+	isFrame   bool
+	receiver  *LoxInstance
+	deferred  []deferredCall
 }
 
-func (env *Environment) assign(name Token, value any) error {
+func (env *Environment) assign(name Token, value Value) error {
+	env.mu.Lock()
 	_, ok := env.values[name.lexeme]
 	if ok {
 		env.values[name.lexeme] = value
+	}
+	env.mu.Unlock()
+	if ok {
 		return nil
 	}
 	if env.enclosing != nil {
@@ -23,36 +35,76 @@ func (env *Environment) assign(name Token, value any) error {
 	return fmt.Errorf("Undefined variable '%s'.", name.lexeme)
 }
 
-func (env *Environment) define(name string, value any) {
+func (env *Environment) define(name string, value Value) {
+	env.mu.Lock()
+	defer env.mu.Unlock()
 	env.values[name] = value
 }
 
-func (env *Environment) get(name Token) (any, error) {
+func (env *Environment) get(name Token) (Value, error) {
+	env.mu.RLock()
 	val, ok := env.values[name.lexeme]
+	env.mu.RUnlock()
 	if ok {
 		return val, nil
 	}
 	if env.enclosing != nil {
 		val, err := env.enclosing.get(name)
 		if err != nil {
-			return nil, err
+			return Value{}, err
 		}
 		return val, nil
 	}
-	return nil, fmt.Errorf("Undefined variable '%s'.", name.lexeme)
+	return Value{}, fmt.Errorf("Undefined variable '%s'.", name.lexeme)
 }
 
-func (env *Environment) getAt(distance int, name string) (any, error) {
+func (env *Environment) getAt(distance int, name string) (Value, error) {
 	ancestor := env.ancestor(distance)
+	ancestor.mu.RLock()
 	val, ok := ancestor.values[name]
+	ancestor.mu.RUnlock()
 	if !ok {
-		return nil, fmt.Errorf("Undefined variable '%s'.", name)
+		return Value{}, fmt.Errorf("Undefined variable '%s'.", name)
 	}
 	return val, nil
 }
 
-func (env *Environment) assignAt(distance int, name Token, value any) {
-	env.ancestor(distance).values[name.lexeme] = value
+func (env *Environment) assignAt(distance int, name Token, value Value) {
+	ancestor := env.ancestor(distance)
+	ancestor.mu.Lock()
+	defer ancestor.mu.Unlock()
+	ancestor.values[name.lexeme] = value
+}
+
+// enclosingGenerator finds the generator whose body env belongs to, if any.
+func (env *Environment) enclosingGenerator() *generatorBody {
+	for output := env; output != nil; output = output.enclosing {
+		if output.generator != nil {
+			return output.generator
+		}
+	}
+	return nil
+}
+
+// enclosingReceiver finds the instance that the decorated method whose call
+// env belongs to was bound to, if any.
+func (env *Environment) enclosingReceiver() *LoxInstance {
+	for output := env; output != nil; output = output.enclosing {
+		if output.receiver != nil {
+			return output.receiver
+		}
+	}
+	return nil
+}
+
+// enclosingFrame finds the environment holding the parameters of the function
+// call that env belongs to.
+func (env *Environment) enclosingFrame() *Environment {
+	output := env
+	for !output.isFrame {
+		output = output.enclosing
+	}
+	return output
 }
 
 func (env *Environment) ancestor(distance int) *Environment {
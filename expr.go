@@ -12,6 +12,14 @@ type Assign struct {
 
 func (a Assign) accept(v ExprVisitor) { v.visitAssign(a) }
 
+type AssignDestructure struct {
+	target DestructuringTarget
+	value  Expr
+	id     int
+}
+
+func (a AssignDestructure) accept(v ExprVisitor) { v.visitAssignDestructure(a) }
+
 type Binary struct {
 	left     Expr
 	operator Token
@@ -22,18 +30,28 @@ type Binary struct {
 func (b Binary) accept(v ExprVisitor) { v.visitBinary(b) }
 
 type Call struct {
-	callee    Expr
-	paren     Token
-	arguments []Expr
-	id        int
+	callee         Expr
+	paren          Token
+	arguments      []Expr
+	namedArguments []NamedArgument
+	id             int
 }
 
 func (c Call) accept(v ExprVisitor) { v.visitCall(c) }
 
+type NamedArgument struct {
+	name  Token
+	value Expr
+}
+
+// classId is the id of the class declaration the expression is written in,
+// or 0 outside of classes. It decides who may access private members.
 type Get struct {
-	object Expr
-	name   Token
-	id     int
+	object   Expr
+	name     Token
+	optional bool
+	classId  int
+	id       int
 }
 
 func (g Get) accept(v ExprVisitor) { v.visitGet(g) }
@@ -45,8 +63,38 @@ type Grouping struct {
 
 func (g Grouping) accept(v ExprVisitor) { v.visitGrouping(g) }
 
+type Index struct {
+	object  Expr
+	bracket Token
+	index   Expr
+	id      int
+}
+
+func (i Index) accept(v ExprVisitor) { v.visitIndex(i) }
+
+type List struct {
+	bracket  Token
+	elements []Expr
+	id       int
+}
+
+func (l List) accept(v ExprVisitor) { v.visitList(l) }
+
+// A Comprehension builds a list from `[element for name in iterable if condition]`.
+// The condition is optional.
+type Comprehension struct {
+	bracket   Token
+	element   Expr
+	name      Token
+	iterable  Expr
+	condition Expr
+	id        int
+}
+
+func (c Comprehension) accept(v ExprVisitor) { v.visitComprehension(c) }
+
 type Literal struct {
-	value any
+	value Value
 	id    int
 }
 
@@ -61,15 +109,45 @@ type Logical struct {
 
 func (l Logical) accept(v ExprVisitor) { v.visitLogical(l) }
 
+// An OptionalChain wraps a chain of calls and property accesses containing
+// `?.`, and evaluates to nil if any of them short-circuits.
+type OptionalChain struct {
+	expression Expr
+	id         int
+}
+
+func (o OptionalChain) accept(v ExprVisitor) { v.visitOptionalChain(o) }
+
+// A Range includes its end for `..` but not for `..<`.
+type Range struct {
+	start    Expr
+	operator Token
+	end      Expr
+	id       int
+}
+
+func (r Range) accept(v ExprVisitor) { v.visitRange(r) }
+
 type Set struct {
-	object Expr
-	name   Token
-	value  Expr
-	id     int
+	object  Expr
+	name    Token
+	value   Expr
+	classId int
+	id      int
 }
 
 func (s Set) accept(v ExprVisitor) { v.visitSet(s) }
 
+// A Spread expands an iterable into the arguments of a call or the elements of
+// a list. It can't appear anywhere else.
+type Spread struct {
+	ellipsis   Token
+	expression Expr
+	id         int
+}
+
+func (s Spread) accept(v ExprVisitor) { v.visitSpread(s) }
+
 type Super struct {
 	keyword Token
 	method  Token
@@ -102,13 +180,20 @@ func (variable Variable) accept(v ExprVisitor) { v.visitVariable(variable) }
 
 type ExprVisitor interface {
 	visitAssign(Assign)
+	visitAssignDestructure(AssignDestructure)
 	visitBinary(Binary)
 	visitCall(Call)
 	visitGet(Get)
 	visitGrouping(Grouping)
+	visitIndex(Index)
+	visitList(List)
+	visitComprehension(Comprehension)
 	visitLiteral(Literal)
 	visitLogical(Logical)
+	visitOptionalChain(OptionalChain)
+	visitRange(Range)
 	visitSet(Set)
+	visitSpread(Spread)
 	visitSuper(Super)
 	visitThis(This)
 	visitUnary(Unary)
@@ -2,16 +2,15 @@ package main
 
 import "time"
 
-type Clock struct{}
+var clockFunction = &NativeFunction{name: "clock", function: func(interp *Interpreter, args []Value) (Value, error) {
+	return intValue(time.Now().Unix()), nil
+}}
 
-func (c Clock) arity() int {
-	return 0
-}
-
-func (c Clock) call(_interp *Interpreter, _args []any) any {
-	return time.Now().Unix()
-}
-
-func (c Clock) String() string {
-	return "<native fn>"
-}
+var hashFunction = &NativeFunction{name: "hash", minArity: 1, maxArity: 1, function: func(interp *Interpreter, args []Value) (Value, error) {
+	hash, err := interp.hash(args[0], interp.callSite)
+	if err != nil {
+		// Already reported
+		return Value{}, nil
+	}
+	return intValue(hash), nil
+}}
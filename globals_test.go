@@ -0,0 +1,17 @@
+package main
+
+import "testing"
+
+func TestClockEqualsItself(t *testing.T) {
+	lx := Lox{}
+	lx.run(`
+assert clock == clock;
+var f = clock;
+assert f == f;
+assert hash(f) == hash(clock);
+assert clock != hash;
+`)
+	if lx.hadError || lx.hadRuntimeError {
+		t.Fatal("clock isn't equal to itself")
+	}
+}
@@ -3,24 +3,31 @@ package main
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"math/rand"
-	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 )
 
 // --------------- INTERPRETER ---------------
 
 type Interpreter struct {
-	output      any
+	output      Value
 	err         error
 	badToken    Token
+	callSite    Token
 	checkReturn bool
-	returnVal   any
-	locals      map[Expr]int
+	returnVal   Value
+	locals      map[int]int
 	env         *Environment
 	lx          *Lox
 }
 
+// Interpret runs the program, then waits for the calls it spawned to finish,
+// so that their output and errors aren't cut off.
 func (interp *Interpreter) Interpret(statements []Stmt) {
+	defer interp.lx.spawned.Wait()
 	for _, statement := range statements {
 		_, _, err, _ := execStmt(statement, interp.env, interp.locals, interp.lx)
 		if err != nil {
@@ -29,8 +36,11 @@ func (interp *Interpreter) Interpret(statements []Stmt) {
 	}
 }
 
-func (interp *Interpreter) resolve(expr Expr, depth int) {
-	interp.locals[expr] = depth
+// resolve records how many scopes away the variable referenced by the
+// expression with the given id lives. Expressions are keyed by id because they
+// aren't all hashable.
+func (interp *Interpreter) resolve(id int, depth int) {
+	interp.locals[id] = depth
 }
 
 // --------------- STATEMENTS ---------------
@@ -39,14 +49,93 @@ func (interp *Interpreter) execute(stmt Stmt) {
 	stmt.accept(interp)
 }
 
-func execStmt(stmt Stmt, env *Environment, locals map[Expr]int, lx *Lox) (any, bool, error, Token) {
+func execStmt(stmt Stmt, env *Environment, locals map[int]int, lx *Lox) (Value, bool, error, Token) {
 	dummyInterp := &Interpreter{env: env, locals: locals, lx: lx}
 	dummyInterp.execute(stmt)
 	return dummyInterp.returnVal, dummyInterp.checkReturn, dummyInterp.err, dummyInterp.badToken
 }
 
+// visitAssert evaluates the operands of a binary or unary condition itself,
+// so that their values can be shown when the assertion fails.
+func (interp *Interpreter) visitAssert(stmt Assert) {
+	if interp.lx.noAsserts {
+		return
+	}
+	var operands []string
+	var operandValues []Value
+	switch condition := stmt.condition.(type) {
+	case Binary:
+		left, err, badToken := evalExpr(condition.left, interp.env, interp.locals, interp.lx)
+		if err != nil {
+			interp.err = err
+			interp.badToken = badToken
+			return
+		}
+		right, err, badToken := evalExpr(condition.right, interp.env, interp.locals, interp.lx)
+		if err != nil {
+			interp.err = err
+			interp.badToken = badToken
+			return
+		}
+		interp.binaryOperation(condition.operator, left, right)
+		operands, operandValues = []string{"left", "right"}, []Value{left, right}
+	case Unary:
+		right, err, badToken := evalExpr(condition.right, interp.env, interp.locals, interp.lx)
+		if err != nil {
+			interp.err = err
+			interp.badToken = badToken
+			return
+		}
+		interp.unaryOperation(condition.operator, right)
+		operands, operandValues = []string{"operand"}, []Value{right}
+	default:
+		interp.output, interp.err, interp.badToken = evalExpr(condition, interp.env, interp.locals, interp.lx)
+	}
+	if interp.err != nil || isTruthy(interp.output) {
+		return
+	}
+	text := "Assertion failed: " + stmt.source
+	if len(operands) > 0 {
+		descriptions := make([]string, len(operands))
+		for i, operand := range operandValues {
+			description, err := interp.describe(operand, stmt.keyword)
+			if err != nil {
+				return
+			}
+			descriptions[i] = operands[i] + " was " + description
+		}
+		text += " (" + strings.Join(descriptions, ", ") + ")"
+	}
+	text += "."
+	if stmt.message != nil {
+		message, err, badToken := evalExpr(stmt.message, interp.env, interp.locals, interp.lx)
+		if err != nil {
+			interp.err = err
+			interp.badToken = badToken
+			return
+		}
+		display, err := interp.display(message, stmt.keyword)
+		if err != nil {
+			return
+		}
+		text += " " + display
+	}
+	err := errors.New(text)
+	interp.lx.RuntimeError(stmt.keyword, err)
+	interp.err = err
+	interp.badToken = stmt.keyword
+}
+
+// describe is like display, but quotes strings so they stand out in messages.
+func (interp *Interpreter) describe(val Value, token Token) (string, error) {
+	if val.isString() {
+		return strconv.Quote(val.asString()), nil
+	}
+	return interp.display(val, token)
+}
+
 func (interp *Interpreter) visitBlock(stmt Block) {
-	interp.executeBlock(stmt.statments, &Environment{values: make(map[string]any), enclosing: interp.env, id: rand.Int()})
+	interp.executeBlock(stmt.statments, &Environment{values: make(map[string]Value), enclosing: interp.env, id: rand.Int()})
 }
 
 func (interp *Interpreter) executeBlock(statements []Stmt, env *Environment) {
@@ -66,7 +155,17 @@ func (interp *Interpreter) executeBlock(statements []Stmt, env *Environment) {
 }
 
 func (interp *Interpreter) visitClass(stmt Class) {
-	var superclass any
+	decorators, ok := interp.evaluateDecorators(stmt.decorators)
+	if !ok {
+		return
+	}
+	methodDecorators := make([][]Value, len(stmt.methods))
+	for i, method := range stmt.methods {
+		if methodDecorators[i], ok = interp.evaluateDecorators(method.decorators); !ok {
+			return
+		}
+	}
+	var superclass Value
 	if stmt.superclass.id > 0 {
 		var superclassErr error
 		var superclassBadToken Token
@@ -76,7 +175,7 @@ func (interp *Interpreter) visitClass(stmt Class) {
 			interp.badToken = superclassBadToken
 			return
 		}
-		switch superclass.(type) {
+		switch superclass.ref.(type) {
 		case LoxClass:
 			break
 		default:
@@ -87,25 +186,94 @@ func (interp *Interpreter) visitClass(stmt Class) {
 			return
 		}
 	}
-	super, ok := superclass.(LoxClass)
-	interp.env.define(stmt.name.lexeme, nil)
+	super, ok := superclass.ref.(LoxClass)
+	interfaces := make([]*LoxInterface, 0)
+	for _, variable := range stmt.interfaces {
+		value, err, badToken := evalExpr(variable, interp.env, interp.locals, interp.lx)
+		if err != nil {
+			interp.err = err
+			interp.badToken = badToken
+			return
+		}
+		iface, ok := value.ref.(*LoxInterface)
+		if !ok {
+			err := fmt.Errorf("Can only implement interfaces.")
+			interp.lx.RuntimeError(variable.name, err)
+			interp.err = err
+			interp.badToken = variable.name
+			return
+		}
+		interfaces = append(interfaces, iface)
+	}
+	interp.env.define(stmt.name.lexeme, Value{})
 	env := interp.env
 	if stmt.superclass.id > 0 {
-		env = &Environment{values: make(map[string]any), enclosing: interp.env, id: rand.Int()}
-		env.define("super", super)
+		env = &Environment{values: make(map[string]Value), enclosing: interp.env, id: rand.Int()}
+		env.define("super", objectValue(super))
 	}
 	methods := make(map[string]LoxFunction)
-	for _, method := range stmt.methods {
+	abstract := make([]string, 0)
+	for i, method := range stmt.methods {
+		if method.isAbstract {
+			abstract = append(abstract, method.name.lexeme)
+			continue
+		}
 		function := LoxFunction{declaration: method, env: env, isInitializer: method.name.lexeme == "init"}
+		if len(methodDecorators[i]) > 0 {
+			decorated, decoratedOk := interp.decorateMethod(function, methodDecorators[i])
+			if !decoratedOk {
+				return
+			}
+			function = decorated
+		}
 		methods[method.name.lexeme] = function
 	}
 	var klass LoxClass
 	if ok {
-		klass = LoxClass{name: stmt.name.lexeme, methods: methods, superclass: &super}
+		klass = LoxClass{name: stmt.name.lexeme, methods: methods, abstract: abstract, interfaces: interfaces, superclass: &super, id: stmt.id}
 	} else {
-		klass = LoxClass{name: stmt.name.lexeme, methods: methods, superclass: nil}
+		klass = LoxClass{name: stmt.name.lexeme, methods: methods, abstract: abstract, interfaces: interfaces, superclass: nil, id: stmt.id}
+	}
+	// Abstract classes may leave methods for their subclasses to implement
+	if len(abstract) == 0 {
+		if method, owner, missing := klass.missingMethod(); missing {
+			err := fmt.Errorf("Class '%s' must implement '%s' from '%s'.", klass.name, method, owner)
+			interp.lx.RuntimeError(stmt.name, err)
+			interp.err = err
+			interp.badToken = stmt.name
+			return
+		}
+	}
+	decorated, ok := interp.decorate(objectValue(klass), decorators, stmt.name)
+	if !ok {
+		return
+	}
+	interp.env.assign(stmt.name, decorated)
+}
+
+func (interp *Interpreter) visitInterface(stmt Interface) {
+	methods := make([]string, 0)
+	for _, method := range stmt.methods {
+		methods = append(methods, method.name.lexeme)
+	}
+	interp.env.define(stmt.name.lexeme, objectValue(&LoxInterface{name: stmt.name.lexeme, methods: methods}))
+}
+
+func (interp *Interpreter) visitDefer(stmt Defer) {
+	function, arguments, ok := interp.evaluateCall(stmt.call)
+	if !ok {
+		return
+	}
+	frame := interp.env.enclosingFrame()
+	frame.deferred = append(frame.deferred, deferredCall{function: function, arguments: arguments, callSite: stmt.call.paren})
+}
+
+func (interp *Interpreter) visitEnum(stmt Enum) {
+	enum := &LoxEnum{name: stmt.name.lexeme, members: make([]*LoxEnumMember, 0)}
+	for i, member := range stmt.members {
+		enum.members = append(enum.members, &LoxEnumMember{enum: enum, name: member.lexeme, ordinal: int64(i)})
 	}
-	interp.env.assign(stmt.name, klass)
+	interp.env.define(stmt.name.lexeme, objectValue(enum))
 }
 
 func (interp *Interpreter) visitExpression(stmt Expression) {
@@ -117,9 +285,124 @@ func (interp *Interpreter) visitExpression(stmt Expression) {
 	}
 }
 
+func (interp *Interpreter) visitForIn(stmt ForIn) {
+	iterable, iterableErr, iterableBadToken := evalExpr(stmt.iterable, interp.env, interp.locals, interp.lx)
+	if iterableErr != nil {
+		interp.err = iterableErr
+		interp.badToken = iterableBadToken
+		return
+	}
+	next, iterateErr := interp.iterate(iterable, stmt.keyword)
+	if iterateErr != nil {
+		return
+	}
+	for {
+		value, ok, nextErr := next()
+		if nextErr != nil {
+			interp.err = nextErr
+			return
+		}
+		if !ok {
+			return
+		}
+		// Each iteration gets a fresh variable so closures capture its own value
+		env := &Environment{values: map[string]Value{stmt.name.lexeme: value}, enclosing: interp.env, id: rand.Int()}
+		returnVal, checkReturn, bodyErr, bodyBadToken := execStmt(stmt.body, env, interp.locals, interp.lx)
+		if bodyErr != nil {
+			interp.err = bodyErr
+			interp.badToken = bodyBadToken
+			return
+		}
+		if checkReturn {
+			interp.returnVal = returnVal
+			interp.checkReturn = checkReturn
+			return
+		}
+	}
+}
+
 func (interp *Interpreter) visitFunction(stmt Function) {
+	decorators, ok := interp.evaluateDecorators(stmt.decorators)
+	if !ok {
+		return
+	}
 	function := LoxFunction{declaration: stmt, env: interp.env, isInitializer: false}
-	interp.env.define(stmt.name.lexeme, function)
+	decorated, ok := interp.decorate(objectValue(function), decorators, stmt.name)
+	if !ok {
+		return
+	}
+	interp.env.define(stmt.name.lexeme, decorated)
+}
+
+func (interp *Interpreter) evaluateDecorators(exprs []Expr) ([]Value, bool) {
+	decorators := make([]Value, len(exprs))
+	for i, expr := range exprs {
+		decorator, err, badToken := evalExpr(expr, interp.env, interp.locals, interp.lx)
+		if err != nil {
+			interp.err = err
+			interp.badToken = badToken
+			return nil, false
+		}
+		decorators[i] = decorator
+	}
+	return decorators, true
+}
+
+// decorate passes value through each decorator, starting with the one
+// closest to the declaration. Errors are reported at token.
+func (interp *Interpreter) decorate(value Value, decorators []Value, token Token) (Value, bool) {
+	for i := len(decorators) - 1; i >= 0; i-- {
+		decorator, ok := decorators[i].ref.(LoxCallable)
+		if !ok {
+			err := fmt.Errorf("Decorators must be functions or classes.")
+			interp.lx.RuntimeError(token, err)
+			interp.err = err
+			interp.badToken = token
+			return Value{}, false
+		}
+		if err := checkArity(decorator, 1); err != nil {
+			interp.lx.RuntimeError(token, err)
+			interp.err = err
+			interp.badToken = token
+			return Value{}, false
+		}
+		interp.callSite = token
+		value = decorator.call(interp, []Value{value})
+		if interp.err != nil {
+			return Value{}, false
+		}
+	}
+	return value, true
+}
+
+// decorateMethod decorates method as an unboundMethod, since the instance it
+// will be called on isn't known yet. The decorators must return a function,
+// which is then bound like any other method.
+func (interp *Interpreter) decorateMethod(method LoxFunction, decorators []Value) (LoxFunction, bool) {
+	decorated := objectValue(unboundMethod{method: method})
+	for i := len(decorators) - 1; i >= 0; i-- {
+		var ok bool
+		if decorated, ok = interp.decorate(decorated, decorators[i:i+1], method.declaration.name); !ok {
+			return LoxFunction{}, false
+		}
+		// Marked after each decorator, so that the wrapper of the next one
+		// binds it as well
+		if function, ok := decorated.ref.(LoxFunction); ok {
+			function.isDecorated = true
+			decorated = objectValue(function)
+		}
+	}
+	switch function := decorated.ref.(type) {
+	case LoxFunction:
+		return function, true
+	case unboundMethod:
+		return function.method, true
+	}
+	err := fmt.Errorf("Method decorators must return a function.")
+	interp.lx.RuntimeError(method.declaration.name, err)
+	interp.err = err
+	interp.badToken = method.declaration.name
+	return LoxFunction{}, false
 }
 
 func (interp *Interpreter) visitIf(stmt If) {
@@ -150,6 +433,105 @@ func (interp *Interpreter) visitIf(stmt If) {
 	}
 }
 
+func (interp *Interpreter) visitMatch(stmt Match) {
+	subject, subjectErr, subjectBadToken := evalExpr(stmt.subject, interp.env, interp.locals, interp.lx)
+	if subjectErr != nil {
+		interp.err = subjectErr
+		interp.badToken = subjectBadToken
+		return
+	}
+	for _, matchCase := range stmt.cases {
+		env := &Environment{values: make(map[string]Value), enclosing: interp.env, id: rand.Int()}
+		matched := len(matchCase.patterns) == 0
+		for _, pattern := range matchCase.patterns {
+			var matchErr error
+			matched, matchErr = interp.matchPattern(pattern, subject, env)
+			if matchErr != nil {
+				return
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		if matchCase.guard != nil {
+			guardVal, guardErr, guardBadToken := evalExpr(matchCase.guard, env, interp.locals, interp.lx)
+			if guardErr != nil {
+				interp.err = guardErr
+				interp.badToken = guardBadToken
+				return
+			}
+			if !isTruthy(guardVal) {
+				continue
+			}
+		}
+		returnVal, checkReturn, bodyErr, bodyBadToken := execStmt(matchCase.body, env, interp.locals, interp.lx)
+		if bodyErr != nil {
+			interp.err = bodyErr
+			interp.badToken = bodyBadToken
+			return
+		}
+		interp.returnVal = returnVal
+		interp.checkReturn = checkReturn
+		return
+	}
+}
+
+// matchPattern reports whether value matches pattern, defining any variables
+// the pattern binds in env.
+func (interp *Interpreter) matchPattern(pattern Pattern, value Value, env *Environment) (bool, error) {
+	switch p := pattern.(type) {
+	case LiteralPattern:
+		return isEqual(p.value, value), nil
+	case ValuePattern:
+		constant, constantErr, constantBadToken := evalExpr(p.value, env, interp.locals, interp.lx)
+		if constantErr != nil {
+			interp.err = constantErr
+			interp.badToken = constantBadToken
+			return false, constantErr
+		}
+		return interp.equals(value, constant, p.token)
+	case BindingPattern:
+		if p.name.lexeme != "_" {
+			env.define(p.name.lexeme, value)
+		}
+		return true, nil
+	case ClassPattern:
+		class, classErr, classBadToken := evalExpr(p.class, env, interp.locals, interp.lx)
+		if classErr != nil {
+			interp.err = classErr
+			interp.badToken = classBadToken
+			return false, classErr
+		}
+		klass, ok := class.ref.(LoxClass)
+		if !ok {
+			err := fmt.Errorf("Can only match instances against a class.")
+			interp.lx.RuntimeError(p.class.name, err)
+			interp.err = err
+			interp.badToken = p.class.name
+			return false, err
+		}
+		instance, ok := value.ref.(LoxInstance)
+		if !ok || !instance.klass.isSubclassOf(klass) {
+			return false, nil
+		}
+		for _, field := range p.fields {
+			fieldVal, ok := instance.field(field.name.lexeme)
+			if !ok {
+				return false, nil
+			}
+			matched, err := interp.matchPattern(field.pattern, fieldVal, env)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
 func (interp *Interpreter) visitPrint(stmt Print) {
 	val, err, badToken := evalExpr(stmt.expr, interp.env, interp.locals, interp.lx)
 	if err != nil {
@@ -157,15 +539,26 @@ func (interp *Interpreter) visitPrint(stmt Print) {
 		interp.badToken = badToken
 		return
 	}
-	if val == nil {
-		fmt.Print("nil\n")
-	} else {
-		fmt.Printf("%v\n", val)
+	text, displayErr := interp.display(val, stmt.keyword)
+	if displayErr != nil {
+		return
 	}
+	fmt.Println(text)
 }
 
 func (interp *Interpreter) visitReturn(stmt Return) {
-	var value any
+	if call, ok := stmt.value.(Call); ok {
+		// The call is left for the returning function to make, after its own
+		// frames are gone
+		function, arguments, ok := interp.evaluateCall(call)
+		if !ok {
+			return
+		}
+		interp.returnVal = objectValue(tailCall{function: function, arguments: arguments, callSite: call.paren})
+		interp.checkReturn = true
+		return
+	}
+	var value Value
 	if stmt.value != nil {
 		var valueErr error
 		var valueBadToken Token
@@ -180,8 +573,23 @@ func (interp *Interpreter) visitReturn(stmt Return) {
 	interp.checkReturn = true
 }
 
+func (interp *Interpreter) visitSpawn(stmt Spawn) {
+	function, arguments, ok := interp.evaluateCall(stmt.call)
+	if !ok {
+		return
+	}
+	interp.lx.spawned.Add(1)
+	go func() {
+		defer interp.lx.spawned.Done()
+		// The spawned call gets an interpreter of its own, so it never shares
+		// returnVal, checkReturn or err with the spawning code.
+		worker := &Interpreter{env: interp.env, locals: interp.locals, lx: interp.lx, callSite: stmt.call.paren}
+		function.call(worker, arguments)
+	}()
+}
+
 func (interp *Interpreter) visitVar(stmt Var) {
-	var value any
+	var value Value
 	if stmt.initializer != nil {
 		val, err, badToken := evalExpr(stmt.initializer, interp.env, interp.locals, interp.lx)
 		if err != nil {
@@ -194,6 +602,66 @@ func (interp *Interpreter) visitVar(stmt Var) {
 	interp.env.define(stmt.name.lexeme, value)
 }
 
+func (interp *Interpreter) visitVarDestructure(stmt VarDestructure) {
+	value, err, badToken := evalExpr(stmt.initializer, interp.env, interp.locals, interp.lx)
+	if err != nil {
+		interp.err = err
+		interp.badToken = badToken
+		return
+	}
+	values, destructureErr := interp.destructure(stmt.target, value)
+	if destructureErr != nil {
+		return
+	}
+	for i, variable := range stmt.target.variables() {
+		interp.env.define(variable.name.lexeme, values[i])
+	}
+}
+
+// destructure pulls the values for each of target's variables out of value, in
+// the same order as target.variables().
+func (interp *Interpreter) destructure(target DestructuringTarget, value Value) ([]Value, error) {
+	var err error
+	values := make([]Value, 0)
+	if target.open.tokenType == LEFT_BRACE {
+		instance, ok := value.ref.(LoxInstance)
+		if !ok {
+			err = fmt.Errorf("Can only destructure properties of instances.")
+		}
+		for _, element := range target.elements {
+			if err != nil {
+				break
+			}
+			var property Value
+			property, err = instance.get(element.key)
+			values = append(values, property)
+		}
+	} else {
+		list, ok := value.ref.(*LoxList)
+		if !ok {
+			err = fmt.Errorf("Can only destructure a list.")
+		} else if len(list.elements) < len(target.elements) {
+			err = fmt.Errorf("Expected at least %d values to destructure but got %d.", len(target.elements), len(list.elements))
+		} else if target.rest.id == 0 && len(list.elements) > len(target.elements) {
+			err = fmt.Errorf("Expected %d values to destructure but got %d.", len(target.elements), len(list.elements))
+		} else {
+			values = append(values, list.elements[:len(target.elements)]...)
+			if target.rest.id > 0 {
+				rest := make([]Value, 0)
+				rest = append(rest, list.elements[len(target.elements):]...)
+				values = append(values, objectValue(&LoxList{elements: rest}))
+			}
+		}
+	}
+	if err != nil {
+		interp.lx.RuntimeError(target.open, err)
+		interp.err = err
+		interp.badToken = target.open
+		return nil, err
+	}
+	return values, nil
+}
+
 func (interp *Interpreter) visitWhile(stmt While) {
 	for {
 		conditionVal, conditionErr, conditionBadToken := evalExpr(stmt.condition, interp.env, interp.locals, interp.lx)
@@ -219,36 +687,90 @@ func (interp *Interpreter) visitWhile(stmt While) {
 	}
 }
 
+func (interp *Interpreter) visitYield(stmt Yield) {
+	var value Value
+	if stmt.value != nil {
+		var valueErr error
+		var valueBadToken Token
+		value, valueErr, valueBadToken = evalExpr(stmt.value, interp.env, interp.locals, interp.lx)
+		if valueErr != nil {
+			interp.err = valueErr
+			interp.badToken = valueBadToken
+			return
+		}
+	}
+	if err := interp.env.enclosingGenerator().yield(value); err != nil {
+		interp.err = err
+		interp.badToken = stmt.keyword
+	}
+}
+
 // --------------- EXPRESSIONS ---------------
 
 func (interp *Interpreter) evaluate(expr Expr) {
 	expr.accept(interp)
 }
 
-func evalExpr(expr Expr, env *Environment, local map[Expr]int, lx *Lox) (any, error, Token) {
+func evalExpr(expr Expr, env *Environment, local map[int]int, lx *Lox) (Value, error, Token) {
 	dummyInterp := Interpreter{env: env, locals: local, lx: lx}
 	dummyInterp.evaluate(expr)
 	return dummyInterp.output, dummyInterp.err, dummyInterp.badToken
 }
 
+// evaluateOperand evaluates a subexpression on interp itself instead of on a
+// new interpreter, which saves an allocation per operand on hot paths. The
+// output and error are cleared again so the caller starts from a clean slate.
+func (interp *Interpreter) evaluateOperand(expr Expr) (Value, error, Token) {
+	interp.evaluate(expr)
+	output, err, badToken := interp.output, interp.err, interp.badToken
+	interp.output, interp.err = Value{}, nil
+	return output, err, badToken
+}
+
 func (interp *Interpreter) visitAssign(expr Assign) {
-	value, err, badToken := evalExpr(expr.value, interp.env, interp.locals, interp.lx)
+	value, err, badToken := interp.evaluateOperand(expr.value)
 	if err != nil {
-		interp.output = NIL
+		interp.output = Value{}
 		interp.err = err
 		interp.badToken = badToken
 		return
 	}
-	distance, ok := interp.locals[expr]
+	if assignErr := interp.assignVariable(expr.id, expr.name, value); assignErr != nil {
+		return
+	}
+	interp.output = value
+}
+
+func (interp *Interpreter) assignVariable(id int, name Token, value Value) error {
+	distance, ok := interp.locals[id]
 	if ok {
-		interp.env.assignAt(distance, expr.name, value)
+		interp.env.assignAt(distance, name, value)
 	} else {
 		globals := interp.getGlobals()
-		assignErr := globals.assign(expr.name, value)
+		assignErr := globals.assign(name, value)
 		if assignErr != nil {
-			interp.lx.RuntimeError(expr.name, assignErr)
+			interp.lx.RuntimeError(name, assignErr)
 			interp.err = assignErr
-			interp.badToken = expr.name
+			interp.badToken = name
+			return assignErr
+		}
+	}
+	return nil
+}
+
+func (interp *Interpreter) visitAssignDestructure(expr AssignDestructure) {
+	value, err, badToken := interp.evaluateOperand(expr.value)
+	if err != nil {
+		interp.err = err
+		interp.badToken = badToken
+		return
+	}
+	values, destructureErr := interp.destructure(expr.target, value)
+	if destructureErr != nil {
+		return
+	}
+	for i, variable := range expr.target.variables() {
+		if assignErr := interp.assignVariable(variable.id, variable.name, values[i]); assignErr != nil {
 			return
 		}
 	}
@@ -256,136 +778,374 @@ func (interp *Interpreter) visitAssign(expr Assign) {
 }
 
 func (interp *Interpreter) visitBinary(expr Binary) {
-	left, err, token := evalExpr(expr.left, interp.env, interp.locals, interp.lx)
+	left, err, token := interp.evaluateOperand(expr.left)
 	if err != nil {
-		interp.output = nil
+		interp.output = Value{}
 		interp.err = err
 		interp.badToken = token
 		return
 	}
-	right, err, token := evalExpr(expr.right, interp.env, interp.locals, interp.lx)
+	right, err, token := interp.evaluateOperand(expr.right)
 	if err != nil {
-		interp.output = nil
+		interp.output = Value{}
 		interp.err = err
 		interp.badToken = token
 		return
 	}
-	switch expr.operator.tokenType {
+	interp.binaryOperation(expr.operator, left, right)
+}
+
+// binaryOperation applies operator to operands that have already been
+// evaluated.
+func (interp *Interpreter) binaryOperation(operator Token, left Value, right Value) {
+	if operator.tokenType == IS {
+		var result bool
+		result, interp.err = isInstance(left, right)
+		interp.output = boolValue(result)
+		if interp.err != nil {
+			interp.lx.RuntimeError(operator, interp.err)
+			interp.badToken = operator
+		}
+		return
+	}
+	if interp.binaryOverload(operator, left, right) {
+		return
+	}
+	switch operator.tokenType {
 	case BANG_EQUAL:
-		interp.output = !isEqual(left, right)
-		interp.err = nil
+		equal, err := interp.equals(left, right, operator)
+		if err == nil {
+			interp.output = boolValue(!equal)
+		}
 	case EQUAL_EQUAL:
-		interp.output = isEqual(left, right)
-		interp.err = nil
-	case GREATER:
-		leftVal, rightVal, err := toFloatPair(left, right)
-		interp.getReturnVal(leftVal > rightVal, err, expr.operator)
-	case GREATER_EQUAL:
-		leftVal, rightVal, err := toFloatPair(left, right)
-		interp.getReturnVal(leftVal >= rightVal, err, expr.operator)
-	case LESS:
-		leftVal, rightVal, err := toFloatPair(left, right)
-		interp.getReturnVal(leftVal < rightVal, err, expr.operator)
-	case LESS_EQUAL:
-		leftVal, rightVal, err := toFloatPair(left, right)
-		interp.getReturnVal(leftVal <= rightVal, err, expr.operator)
-	case MINUS:
-		leftVal, rightVal, err := toFloatPair(left, right)
-		interp.getReturnVal(leftVal-rightVal, err, expr.operator)
-	case SLASH:
-		leftVal, rightVal, err := toFloatPair(left, right)
-		if err == nil && rightVal == 0 {
-			interp.getReturnVal(0, errors.New("Dividing by zero"), expr.operator)
-			return
-		}
-		interp.getReturnVal(leftVal/rightVal, err, expr.operator)
-	case STAR:
-		leftVal, rightVal, err := toFloatPair(left, right)
-		interp.getReturnVal(leftVal*rightVal, err, expr.operator)
+		equal, err := interp.equals(left, right, operator)
+		if err == nil {
+			interp.output = boolValue(equal)
+		}
+	case GREATER, GREATER_EQUAL, LESS, LESS_EQUAL:
+		result, err := compare(operator.tokenType, left, right)
+		interp.getReturnVal(boolValue(result), err, operator)
+	case MINUS, SLASH, STAR:
+		result, err := arithmetic(operator.tokenType, left, right)
+		interp.getReturnVal(result, err, operator)
 	case PLUS:
 		// numeric case
-		leftVal, rightVal, err := toFloatPair(left, right)
-		if err == nil {
-			interp.getReturnVal(leftVal+rightVal, err, expr.operator)
+		if left.isNumber() && right.isNumber() {
+			result, err := arithmetic(PLUS, left, right)
+			interp.getReturnVal(result, err, operator)
 			return
 		}
-		// string case
-		leftString, rightString, err := toStringPair(left, right)
-		if err != nil {
-			interp.lx.RuntimeError(expr.operator, err)
+		// string case, where instances are converted with toString
+		if left.isString() || right.isString() {
+			var displayErr error
+			if left, displayErr = interp.concatOperand(left, operator); displayErr != nil {
+				return
+			}
+			if right, displayErr = interp.concatOperand(right, operator); displayErr != nil {
+				return
+			}
+		}
+		if !left.isString() || !right.isString() {
+			err := fmt.Errorf("Operands must be two numbers or two strings.")
+			interp.lx.RuntimeError(operator, err)
 			interp.err = err
-			interp.badToken = expr.operator
+			interp.badToken = operator
 			return
 		}
-		interp.getReturnVal(leftString+rightString, err, expr.operator)
+		interp.output = stringValue(left.asString() + right.asString())
 	}
 }
 
+func (interp *Interpreter) concatOperand(val Value, token Token) (Value, error) {
+	if _, _, ok := protocolMethod(val, "toString"); ok {
+		text, err := interp.display(val, token)
+		return stringValue(text), err
+	}
+	return val, nil
+}
+
 func (interp *Interpreter) visitCall(expr Call) {
-	callee, calleeErr, calleeBadToken := evalExpr(expr.callee, interp.env, interp.locals, interp.lx)
+	function, arguments, ok := interp.evaluateCall(expr)
+	if !ok {
+		return
+	}
+	interp.callSite = expr.paren
+	interp.output = function.call(interp, arguments)
+}
+
+// evaluateCall evaluates the callee and arguments of expr and checks them
+// against the callee's arity, without making the call.
+func (interp *Interpreter) evaluateCall(expr Call) (LoxCallable, []Value, bool) {
+	callee, calleeErr, calleeBadToken := interp.evaluateOperand(expr.callee)
 	if calleeErr != nil {
 		interp.err = calleeErr
 		interp.badToken = calleeBadToken
-		return
+		return nil, nil, false
+	}
+	arguments, ok := interp.evaluateElements(expr.arguments)
+	if !ok {
+		return nil, nil, false
 	}
-	arguments := make([]any, 0)
-	for _, argument := range expr.arguments {
-		arg, argErr, argBadToken := evalExpr(argument, interp.env, interp.locals, interp.lx)
+	var namedArguments map[string]Value
+	if len(expr.namedArguments) > 0 {
+		namedArguments = make(map[string]Value, len(expr.namedArguments))
+	}
+	for _, argument := range expr.namedArguments {
+		arg, argErr, argBadToken := interp.evaluateOperand(argument.value)
 		if argErr != nil {
 			interp.err = argErr
 			interp.badToken = argBadToken
-			return
+			return nil, nil, false
 		}
-		arguments = append(arguments, arg)
+		namedArguments[argument.name.lexeme] = arg
 	}
-	switch function := callee.(type) {
+	switch function := callee.ref.(type) {
 	case LoxCallable:
-		if len(arguments) != function.arity() {
-			err := fmt.Errorf("Expected %d arguments but got %d.", function.arity(), len(arguments))
+		var err error
+		if len(expr.namedArguments) > 0 {
+			arguments, err = arrangeArguments(function, arguments, namedArguments)
+		} else {
+			err = checkArity(function, len(arguments))
+		}
+		if err != nil {
 			interp.lx.RuntimeError(expr.paren, err)
 			interp.err = err
 			interp.badToken = expr.paren
-			return
+			return nil, nil, false
 		}
-		interp.output = function.call(interp, arguments)
+		return function, arguments, true
 	default:
 		err := fmt.Errorf("Can only call functions and classes.")
 		interp.lx.RuntimeError(expr.paren, err)
 		interp.err = err
 		interp.badToken = expr.paren
+		return nil, nil, false
 	}
 }
 
 func (interp *Interpreter) visitGet(expr Get) {
-	object, objectErr, objectBadToken := evalExpr(expr.object, interp.env, interp.locals, interp.lx)
+	object, objectErr, objectBadToken := interp.evaluateOperand(expr.object)
 	if objectErr != nil {
 		interp.err = objectErr
 		interp.badToken = objectBadToken
 		return
 	}
-	switch li := object.(type) {
+	if expr.optional && object.isNil() {
+		// Unwinds like an error until the enclosing OptionalChain
+		interp.err = errShortCircuit
+		interp.badToken = expr.name
+		return
+	}
+	var val Value
+	var getErr error
+	switch li := object.ref.(type) {
 	case LoxInstance:
-		val, getErr := li.get(expr.name)
-		if getErr != nil {
-			interp.lx.RuntimeError(expr.name, getErr)
-			interp.err = getErr
-			interp.badToken = expr.name
+		val, getErr = li.getFrom(expr.name, expr.classId)
+	case LoxObject:
+		val, getErr = li.get(expr.name)
+	case string:
+		val, getErr = stringProperty(object, expr.name)
+	default:
+		getErr = fmt.Errorf("Only instances have properties.")
+	}
+	if getErr != nil {
+		interp.lx.RuntimeError(expr.name, getErr)
+		interp.err = getErr
+		interp.badToken = expr.name
+		return
+	}
+	interp.output = val
+}
+
+func (interp *Interpreter) visitGrouping(expr Grouping) {
+	interp.output, interp.err, interp.badToken = interp.evaluateOperand(expr.expression)
+}
+
+func (interp *Interpreter) visitIndex(expr Index) {
+	object, objectErr, objectBadToken := interp.evaluateOperand(expr.object)
+	if objectErr != nil {
+		interp.err = objectErr
+		interp.badToken = objectBadToken
+		return
+	}
+	index, indexErr, indexBadToken := interp.evaluateOperand(expr.index)
+	if indexErr != nil {
+		interp.err = indexErr
+		interp.badToken = indexBadToken
+		return
+	}
+	var err error
+	slice, isSlice := index.ref.(*LoxRange)
+	switch sequence := object.ref.(type) {
+	case string:
+		runes := []rune(sequence)
+		if isSlice {
+			var indices []int
+			if indices, err = slice.indices(len(runes)); err == nil {
+				sliced := make([]rune, len(indices))
+				for i, index := range indices {
+					sliced[i] = runes[index]
+				}
+				interp.output = stringValue(string(sliced))
+			}
+			break
+		}
+		var i int
+		if i, err = toIndex(index, len(runes)); err == nil {
+			interp.output = stringValue(string(runes[i]))
+		}
+	case *LoxList:
+		if isSlice {
+			var indices []int
+			if indices, err = slice.indices(len(sequence.elements)); err == nil {
+				sliced := make([]Value, len(indices))
+				for i, index := range indices {
+					sliced[i] = sequence.elements[index]
+				}
+				interp.output = objectValue(&LoxList{elements: sliced})
+			}
+			break
+		}
+		var i int
+		if i, err = toIndex(index, len(sequence.elements)); err == nil {
+			interp.output = sequence.elements[i]
+		}
+	default:
+		err = fmt.Errorf("Can only index lists and strings.")
+	}
+	if err != nil {
+		interp.lx.RuntimeError(expr.bracket, err)
+		interp.err = err
+		interp.badToken = expr.bracket
+	}
+}
+
+func (interp *Interpreter) visitList(expr List) {
+	elements, ok := interp.evaluateElements(expr.elements)
+	if !ok {
+		return
+	}
+	interp.output = objectValue(&LoxList{elements: elements})
+}
+
+// visitComprehension builds the list eagerly. Like a for-in loop, each element
+// gets a fresh variable so closures capture its own value.
+func (interp *Interpreter) visitComprehension(expr Comprehension) {
+	iterable, err, badToken := interp.evaluateOperand(expr.iterable)
+	if err != nil {
+		interp.err = err
+		interp.badToken = badToken
+		return
+	}
+	next, iterateErr := interp.iterate(iterable, expr.bracket)
+	if iterateErr != nil {
+		return
+	}
+	previous := interp.env
+	defer func() {
+		interp.env = previous
+	}()
+	elements := make([]Value, 0)
+	for {
+		value, ok, nextErr := next()
+		if nextErr != nil {
+			interp.err = nextErr
 			return
-		} else {
-			interp.output = val
+		}
+		if !ok {
+			break
+		}
+		interp.env = &Environment{values: map[string]Value{expr.name.lexeme: value}, enclosing: previous, id: rand.Int()}
+		if expr.condition != nil {
+			condition, err, badToken := interp.evaluateOperand(expr.condition)
+			if err != nil {
+				interp.err = err
+				interp.badToken = badToken
+				return
+			}
+			if !isTruthy(condition) {
+				continue
+			}
+		}
+		element, err, badToken := interp.evaluateOperand(expr.element)
+		if err != nil {
+			interp.err = err
+			interp.badToken = badToken
 			return
 		}
-	default:
-		err := fmt.Errorf("Only instances have properties.")
-		interp.lx.RuntimeError(expr.name, err)
+		elements = append(elements, element)
+	}
+	interp.output = objectValue(&LoxList{elements: elements})
+}
+
+// evaluateElements evaluates the elements of a list or the arguments of a
+// call, expanding any spreads among them.
+func (interp *Interpreter) evaluateElements(exprs []Expr) ([]Value, bool) {
+	values := make([]Value, 0, len(exprs))
+	for _, expr := range exprs {
+		spread, isSpread := expr.(Spread)
+		if isSpread {
+			expr = spread.expression
+		}
+		value, err, badToken := interp.evaluateOperand(expr)
+		if err != nil {
+			interp.err = err
+			interp.badToken = badToken
+			return nil, false
+		}
+		if !isSpread {
+			values = append(values, value)
+			continue
+		}
+		next, iterateErr := interp.iterate(value, spread.ellipsis)
+		if iterateErr != nil {
+			return nil, false
+		}
+		for {
+			element, ok, nextErr := next()
+			if nextErr != nil {
+				interp.err = nextErr
+				return nil, false
+			}
+			if !ok {
+				break
+			}
+			values = append(values, element)
+		}
+	}
+	return values, true
+}
+
+func (interp *Interpreter) visitRange(expr Range) {
+	start, err, badToken := interp.evaluateOperand(expr.start)
+	if err != nil {
 		interp.err = err
-		interp.badToken = expr.name
+		interp.badToken = badToken
 		return
 	}
+	end, err, badToken := interp.evaluateOperand(expr.end)
+	if err != nil {
+		interp.err = err
+		interp.badToken = badToken
+		return
+	}
+	lr, rangeErr := newLoxRange(start, end, expr.operator.tokenType == DOT_DOT)
+	if rangeErr != nil {
+		interp.lx.RuntimeError(expr.operator, rangeErr)
+		interp.err = rangeErr
+		interp.badToken = expr.operator
+		return
+	}
+	interp.output = objectValue(lr)
 }
 
-func (interp *Interpreter) visitGrouping(expr Grouping) {
-	interp.output, interp.err, interp.badToken = evalExpr(expr.expression, interp.env, interp.locals, interp.lx)
+// Spreads are expanded by the list or call containing them, and the parser
+// doesn't allow them anywhere else.
+func (interp *Interpreter) visitSpread(expr Spread) {
+	err := errors.New("Can only spread into arguments and list elements.")
+	interp.lx.RuntimeError(expr.ellipsis, err)
+	interp.err = err
+	interp.badToken = expr.ellipsis
 }
 
 func (interp *Interpreter) visitLiteral(expr Literal) {
@@ -393,7 +1153,7 @@ func (interp *Interpreter) visitLiteral(expr Literal) {
 }
 
 func (interp *Interpreter) visitLogical(expr Logical) {
-	left, leftErr, leftBadToken := evalExpr(expr.left, interp.env, interp.locals, interp.lx)
+	left, leftErr, leftBadToken := interp.evaluateOperand(expr.left)
 	if leftErr != nil {
 		interp.err = leftErr
 		interp.badToken = leftBadToken
@@ -404,13 +1164,18 @@ func (interp *Interpreter) visitLogical(expr Logical) {
 			interp.output = left
 			return
 		}
+	} else if expr.operator.tokenType == QUESTION_QUESTION {
+		if !left.isNil() {
+			interp.output = left
+			return
+		}
 	} else {
 		if !isTruthy(left) {
 			interp.output = left
 			return
 		}
 	}
-	right, rightErr, rightBadToken := evalExpr(expr.right, interp.env, interp.locals, interp.lx)
+	right, rightErr, rightBadToken := interp.evaluateOperand(expr.right)
 	if rightErr != nil {
 		interp.err = rightErr
 		interp.badToken = rightBadToken
@@ -419,22 +1184,41 @@ func (interp *Interpreter) visitLogical(expr Logical) {
 	interp.output = right
 }
 
+// errShortCircuit is raised by `?.` on nil. It is never reported, since the
+// OptionalChain around it always catches it.
+var errShortCircuit = errors.New("Short-circuited optional chain.")
+
+func (interp *Interpreter) visitOptionalChain(expr OptionalChain) {
+	value, err, badToken := interp.evaluateOperand(expr.expression)
+	if err == errShortCircuit {
+		interp.output = Value{}
+		return
+	}
+	interp.output, interp.err, interp.badToken = value, err, badToken
+}
+
 func (interp *Interpreter) visitSet(expr Set) {
-	object, objectErr, objectBadToken := evalExpr(expr.object, interp.env, interp.locals, interp.lx)
+	object, objectErr, objectBadToken := interp.evaluateOperand(expr.object)
 	if objectErr != nil {
 		interp.err = objectErr
 		interp.badToken = objectBadToken
 		return
 	}
-	switch li := object.(type) {
+	switch li := object.ref.(type) {
 	case LoxInstance:
-		value, valueErr, valueBadToken := evalExpr(expr.value, interp.env, interp.locals, interp.lx)
+		value, valueErr, valueBadToken := interp.evaluateOperand(expr.value)
 		if valueErr != nil {
 			interp.err = valueErr
 			interp.badToken = valueBadToken
 			return
 		}
-		li.set(expr.name, value)
+		setErr := li.setFrom(expr.name, value, expr.classId)
+		if setErr != nil {
+			interp.lx.RuntimeError(expr.name, setErr)
+			interp.err = setErr
+			interp.badToken = expr.name
+			return
+		}
 		interp.output = value
 	default:
 		err := fmt.Errorf("Only instances have fields.")
@@ -445,11 +1229,11 @@ func (interp *Interpreter) visitSet(expr Set) {
 }
 
 func (interp *Interpreter) visitSuper(expr Super) {
-	distance := interp.locals[expr]
+	distance := interp.locals[expr.id]
 	super, _ := interp.env.getAt(distance, "super")
-	superclass, _ := super.(LoxClass)
+	superclass, _ := super.ref.(LoxClass)
 	obj, _ := interp.env.getAt(distance-1, "this")
-	object, _ := obj.(LoxInstance)
+	object, _ := obj.ref.(LoxInstance)
 	method, findMethodErr := superclass.findMethod(expr.method.lexeme)
 	if findMethodErr != nil {
 		interp.lx.RuntimeError(expr.method, findMethodErr)
@@ -457,11 +1241,11 @@ func (interp *Interpreter) visitSuper(expr Super) {
 		interp.badToken = expr.method
 		return
 	}
-	interp.output = method.bind(object)
+	interp.output = objectValue(method.bind(object))
 }
 
 func (interp *Interpreter) visitThis(expr This) {
-	value, err := interp.lookUpVariable(expr.keyword, expr)
+	value, err := interp.lookUpVariable(expr.keyword, expr.id)
 	if err != nil {
 		interp.err = err
 		interp.badToken = expr.keyword
@@ -471,41 +1255,48 @@ func (interp *Interpreter) visitThis(expr This) {
 }
 
 func (interp *Interpreter) visitUnary(expr Unary) {
-	right, err, token := evalExpr(expr.right, interp.env, interp.locals, interp.lx)
+	right, err, token := interp.evaluateOperand(expr.right)
 	if err != nil {
-		interp.output = nil
+		interp.output = Value{}
 		interp.err = err
 		interp.badToken = token
 		return
 	}
-	switch expr.operator.tokenType {
+	interp.unaryOperation(expr.operator, right)
+}
+
+func (interp *Interpreter) unaryOperation(operator Token, right Value) {
+	if interp.unaryOverload(operator, right) {
+		return
+	}
+	switch operator.tokenType {
 	case BANG:
-		interp.output = !isTruthy(right)
+		interp.output = boolValue(!isTruthy(right))
 	case MINUS:
-		val, err := toFloat(right)
+		val, err := negate(right)
 		if err != nil {
 			interp.err = err
-			interp.badToken = expr.operator
-			interp.lx.RuntimeError(expr.operator, err)
+			interp.badToken = operator
+			interp.lx.RuntimeError(operator, err)
 			return
 		}
-		interp.output = -val
+		interp.output = val
 	}
 }
 
 func (interp *Interpreter) visitVariable(expr Variable) {
-	val, err := interp.lookUpVariable(expr.name, expr)
+	val, err := interp.lookUpVariable(expr.name, expr.id)
 	if err != nil {
 		interp.err = err
 		interp.badToken = expr.name
 		interp.lx.RuntimeError(expr.name, err)
 		return
 	}
-	interp.output = val
+	interp.output = bindToReceiver(val, interp.env)
 }
 
-func (interp *Interpreter) lookUpVariable(name Token, expr Expr) (any, error) {
-	distance, ok := interp.locals[expr]
+func (interp *Interpreter) lookUpVariable(name Token, id int) (Value, error) {
+	distance, ok := interp.locals[id]
 	if ok {
 		return interp.env.getAt(distance, name.lexeme)
 	} else {
@@ -516,7 +1307,7 @@ func (interp *Interpreter) lookUpVariable(name Token, expr Expr) (any, error) {
 
 // --------------- HELPERS ---------------
 
-func (interp *Interpreter) getReturnVal(okVal any, err error, badToken Token) {
+func (interp *Interpreter) getReturnVal(okVal Value, err error, badToken Token) {
 	if err != nil {
 		interp.lx.RuntimeError(badToken, err)
 		interp.err = err
@@ -526,70 +1317,56 @@ func (interp *Interpreter) getReturnVal(okVal any, err error, badToken Token) {
 	}
 }
 
-func isEqual(left any, right any) bool {
-	if left == nil && right == nil {
-		return true
-	} else if left == nil {
-		return false
+func checkArity(function LoxCallable, count int) error {
+	min, max := function.arity()
+	if min == max && count != min {
+		return fmt.Errorf("Expected %d arguments but got %d.", min, count)
+	} else if count < min {
+		return fmt.Errorf("Expected at least %d arguments but got %d.", min, count)
+	} else if max != VARIADIC && count > max {
+		return fmt.Errorf("Expected at most %d arguments but got %d.", max, count)
 	}
-	return reflect.DeepEqual(left, right)
+	return nil
 }
 
-func toStringPair(left any, right any) (string, string, error) {
-	leftString, leftErr := toString(left)
-	rightString, rightErr := toString(right)
-	if leftErr != nil {
-		return "", "", fmt.Errorf("Operands must be two numbers or two strings.")
-	} else if rightErr != nil {
-		return "", "", fmt.Errorf("Operands must be two numbers or two strings.")
-	} else {
-		return leftString, rightString, nil
+// arrangeArguments places positional and named arguments into parameter order.
+// Skipped parameters with defaults are filled with noArgument, and extra
+// positional arguments are left at the end for a rest parameter.
+func arrangeArguments(function LoxCallable, positional []Value, named map[string]Value) ([]Value, error) {
+	namedCallable, ok := function.(NamedCallable)
+	if !ok {
+		return nil, fmt.Errorf("Can only pass named arguments to functions and classes.")
 	}
-}
-
-func toFloatPair(left any, right any) (float64, float64, error) {
-	leftVal, leftErr := toFloat(left)
-	rightVal, rightErr := toFloat(right)
-	if leftErr != nil {
-		return 0, 0, fmt.Errorf("Operands must be numbers.")
-	} else if rightErr != nil {
-		return 0, 0, fmt.Errorf("Operands must be numbers.")
-	} else {
-		return leftVal, rightVal, nil
+	params := namedCallable.parameters()
+	fixed := len(params)
+	if fixed > 0 && params[fixed-1].variadic {
+		fixed -= 1
 	}
-}
-
-func isTruthy(object any) bool {
-	if object == nil {
-		return false
+	if len(positional) > fixed && fixed == len(params) {
+		return nil, fmt.Errorf("Expected at most %d arguments but got %d.", fixed, len(positional)+len(named))
 	}
-	switch t := object.(type) {
-	case bool:
-		return t
-	default:
-		return true
+	arguments := make([]Value, max(fixed, len(positional)))
+	copy(arguments, positional)
+	for i := len(positional); i < fixed; i++ {
+		arguments[i] = objectValue(noArgument{})
 	}
-}
-
-func toFloat(val any) (float64, error) {
-	switch v := val.(type) {
-	case float32:
-		return float64(v), nil
-	case float64:
-		return v, nil
-	case int:
-		return float64(v), nil
+	for _, name := range slices.Sorted(maps.Keys(named)) {
+		value := named[name]
+		index := slices.IndexFunc(params[:fixed], func(param Param) bool { return param.name.lexeme == name })
+		if index == -1 {
+			return nil, fmt.Errorf("Unknown argument '%s'.", name)
+		}
+		if index < len(positional) {
+			return nil, fmt.Errorf("Got multiple values for argument '%s'.", name)
+		}
+		arguments[index] = value
 	}
-	return 0, errors.New("Operand must be a number.")
-}
-
-func toString(val any) (string, error) {
-	switch v := val.(type) {
-	case string:
-		return v, nil
-	default:
-		return "", errors.New("Converting non-string value to string")
+	for i := range fixed {
+		if arguments[i].ref == (noArgument{}) && params[i].defaultValue == nil {
+			return nil, fmt.Errorf("Missing argument '%s'.", params[i].name.lexeme)
+		}
 	}
+	return arguments, nil
 }
 
 func (interp *Interpreter) getGlobals() *Environment {
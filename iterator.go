@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"unicode/utf8"
+)
+
+// An iterator returns the next value of a sequence, or false once the
+// sequence is exhausted.
+type iterator func() (Value, bool, error)
+
+// Done is what the next() method of an iterator without hasNext() returns once
+// it has no more values.
+type Done struct{}
+
+func (d Done) String() string {
+	return "Done"
+}
+
+// iterate returns an iterator over value. Besides the built-in sequences,
+// instances are iterable if they have an iterator() method returning an
+// iterator, or if they are iterators themselves with a next() method, and
+// optionally hasNext(). Errors are reported at token.
+func (interp *Interpreter) iterate(value Value, token Token) (iterator, error) {
+	switch v := value.ref.(type) {
+	case *LoxList:
+		index := 0
+		return func() (Value, bool, error) {
+			if index >= len(v.elements) {
+				return Value{}, false, nil
+			}
+			index += 1
+			return v.elements[index-1], true, nil
+		}, nil
+	case *LoxRange:
+		index, length := int64(0), v.length()
+		return func() (Value, bool, error) {
+			if index >= length {
+				return Value{}, false, nil
+			}
+			index += 1
+			return intValue(v.at(index - 1)), true, nil
+		}, nil
+	case string:
+		rest := v
+		return func() (Value, bool, error) {
+			if len(rest) == 0 {
+				return Value{}, false, nil
+			}
+			_, size := utf8.DecodeRuneInString(rest)
+			char := rest[:size]
+			rest = rest[size:]
+			return stringValue(char), true, nil
+		}, nil
+	case *LoxGenerator:
+		return func() (Value, bool, error) {
+			if !v.advance(interp) {
+				return Value{}, false, interp.err
+			}
+			v.hasValue = false
+			return v.value, true, nil
+		}, nil
+	case *LoxChannel:
+		return func() (Value, bool, error) {
+			received, ok := <-v.channel
+			return received, ok, nil
+		}, nil
+	case LoxInstance:
+		if _, method, ok := protocolMethod(value, "iterator"); ok {
+			output, err := interp.callMethod(v, method, []Value{}, token)
+			if err != nil {
+				return nil, err
+			}
+			if other, ok := output.ref.(LoxInstance); !ok || !isEqual(objectValue(other), value) {
+				return interp.iterate(output, token)
+			}
+		}
+		_, hasNext, hasNextOk := protocolMethod(value, "hasNext")
+		_, next, nextOk := protocolMethod(value, "next")
+		if hasNextOk && nextOk {
+			return func() (Value, bool, error) {
+				more, err := interp.callMethod(v, hasNext, []Value{}, token)
+				if err != nil || !isTruthy(more) {
+					return Value{}, false, err
+				}
+				output, err := interp.callMethod(v, next, []Value{}, token)
+				return output, err == nil, err
+			}, nil
+		}
+		if nextOk {
+			return func() (Value, bool, error) {
+				output, err := interp.callMethod(v, next, []Value{}, token)
+				if err != nil {
+					return Value{}, false, err
+				}
+				_, done := output.ref.(Done)
+				return output, !done, nil
+			}, nil
+		}
+	}
+	err := errors.New("Can only iterate over lists, strings, ranges, generators, channels and iterable instances.")
+	interp.lx.RuntimeError(token, err)
+	interp.err = err
+	interp.badToken = token
+	return nil, err
+}
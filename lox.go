@@ -7,11 +7,15 @@ import (
 	"math/rand"
 	"os"
 	"strings"
+	"sync"
 )
 
 type Lox struct {
 	hadError        bool
 	hadRuntimeError bool
+	runtimeErrorMu  sync.Mutex
+	noAsserts       bool
+	spawned         sync.WaitGroup
 }
 
 func main() {
@@ -21,8 +25,12 @@ func main() {
 
 func (lx *Lox) main() {
 	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "--no-asserts" {
+		lx.noAsserts = true
+		args = args[1:]
+	}
 	if len(args) > 1 {
-		fmt.Println("Usage: glox [script]")
+		fmt.Println("Usage: glox [--no-asserts] [script]")
 		os.Exit(64)
 	} else if len(args) == 1 {
 		lx.runFile(args[0])
@@ -60,22 +68,39 @@ func (lx *Lox) runPrompt() {
 func (lx *Lox) run(source string) {
 	scanner := Scanner{source: source, tokens: make([]Token, 0), start: 0, current: 0, line: 1, lox: lx}
 	tokens := scanner.ScanTokens()
-	parser := Parser{tokens: tokens, current: 0, lx: lx, idCounter: 1}
+	parser := Parser{source: source, tokens: tokens, current: 0, lx: lx, idCounter: 1}
 	statements, _ := parser.Parse()
 	if lx.hadError {
 		return
 	}
-	globals := &Environment{values: make(map[string]any), id: rand.Int()}
+	globals := &Environment{values: make(map[string]Value), id: rand.Int()}
 	{
 		// Block for defining globals
-		globals.define("clock", Clock{})
+		globals.define("clock", objectValue(clockFunction))
+		globals.define("hash", objectValue(hashFunction))
+		globals.define("Channel", objectValue(channelClass))
+		globals.define("WaitGroup", objectValue(waitGroupClass))
+		globals.define("Done", objectValue(Done{}))
+		globals.define("typeof", objectValue(typeofFunction))
+		globals.define("fields", objectValue(fieldsFunction))
+		globals.define("methods", objectValue(methodsFunction))
+		globals.define("arity", objectValue(arityFunction))
+		globals.define("name", objectValue(nameFunction))
+		globals.define("classOf", objectValue(classOfFunction))
+		globals.define("className", objectValue(classNameFunction))
+		globals.define("superclass", objectValue(superclassFunction))
+		globals.define("instanceof", objectValue(instanceofFunction))
 	}
-	interpreter := Interpreter{env: globals, locals: make(map[Expr]int), lx: lx}
-	resolver := Resolver{interp: &interpreter, scopes: make([]map[string]bool, 0), lx: lx}
+	interpreter := Interpreter{env: globals, locals: make(map[int]int), lx: lx}
+	resolver := Resolver{interp: &interpreter, scopes: make([]map[string]bool, 0), enums: []map[string]Enum{make(map[string]Enum)}, lx: lx}
 	resolver.resolveStatements(statements)
 	if lx.hadError {
 		return
 	}
+	newTypeChecker(lx).checkStatements(statements)
+	if lx.hadError {
+		return
+	}
 	interpreter.Interpret(statements)
 }
 
@@ -100,7 +125,14 @@ func (lx *Lox) ResolveError(token Token, message string) {
 	lx.report(token.line, fmt.Sprintf(" at '%s'", token.lexeme), message)
 }
 
+func (lx *Lox) TypeError(token Token, message string) {
+	lx.report(token.line, fmt.Sprintf(" at '%s'", token.lexeme), message)
+}
+
 func (lx *Lox) RuntimeError(token Token, err error) {
+	// Spawned goroutines can report errors at the same time
+	lx.runtimeErrorMu.Lock()
+	defer lx.runtimeErrorMu.Unlock()
 	lx.hadRuntimeError = true
 	fmt.Fprintf(os.Stderr, "%s\n[line %d]", err.Error(), token.line)
 }
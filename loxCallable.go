@@ -1,6 +1,17 @@
 package main
 
+// Callables accept between min and max arguments. A max of VARIADIC means
+// there is no upper bound.
+const VARIADIC = -1
+
 type LoxCallable interface {
-	arity() int
-	call(*Interpreter, []any) any
+	arity() (int, int)
+	call(*Interpreter, []Value) Value
+}
+
+// NamedCallable is implemented by callables whose parameters can also be
+// passed by name.
+type NamedCallable interface {
+	LoxCallable
+	parameters() []Param
 }
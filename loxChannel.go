@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type LoxChannel struct {
+	channel chan Value
+	mu      sync.Mutex
+	closed  bool
+}
+
+var channelClass = &NativeClass{
+	name: "Channel",
+	constructor: NativeFunction{name: "Channel", minArity: 0, maxArity: 1, function: func(interp *Interpreter, args []Value) (Value, error) {
+		capacity := int64(0)
+		if len(args) == 1 {
+			if args[0].kind != INT_KIND || args[0].asInt() < 0 {
+				return Value{}, errors.New("Channel capacity must be a non-negative integer.")
+			}
+			capacity = args[0].asInt()
+		}
+		return objectValue(&LoxChannel{channel: make(chan Value, capacity)}), nil
+	}},
+	statics: map[string]*NativeFunction{
+		"select": {name: "select", minArity: 1, maxArity: VARIADIC, function: selectChannels},
+	},
+}
+
+// selectChannels receives from whichever of the channels is ready first and
+// returns the channel along with the value, which is nil if it was closed.
+func selectChannels(interp *Interpreter, args []Value) (Value, error) {
+	cases := make([]reflect.SelectCase, len(args))
+	for i, arg := range args {
+		channel, ok := arg.ref.(*LoxChannel)
+		if !ok {
+			return Value{}, errors.New("Can only select on channels.")
+		}
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(channel.channel)}
+	}
+	chosen, value, ok := reflect.Select(cases)
+	var received Value
+	if ok {
+		received = value.Interface().(Value)
+	}
+	return objectValue(&LoxList{elements: []Value{args[chosen], received}}), nil
+}
+
+func (lc *LoxChannel) get(name Token) (Value, error) {
+	switch name.lexeme {
+	case "send":
+		return objectValue(&NativeFunction{name: "send", minArity: 1, maxArity: 1, function: func(interp *Interpreter, args []Value) (Value, error) {
+			return Value{}, lc.send(args[0])
+		}}), nil
+	case "receive":
+		return objectValue(&NativeFunction{name: "receive", function: func(interp *Interpreter, args []Value) (Value, error) {
+			// A closed and drained channel receives nil
+			return <-lc.channel, nil
+		}}), nil
+	case "close":
+		return objectValue(&NativeFunction{name: "close", function: func(interp *Interpreter, args []Value) (Value, error) {
+			lc.mu.Lock()
+			defer lc.mu.Unlock()
+			if lc.closed {
+				return Value{}, errors.New("Channel is already closed.")
+			}
+			lc.closed = true
+			close(lc.channel)
+			return Value{}, nil
+		}}), nil
+	}
+	return Value{}, fmt.Errorf("Undefined property '%s'.", name.lexeme)
+}
+
+func (lc *LoxChannel) send(value Value) (err error) {
+	// Closing can race with a blocked send, which makes Go panic
+	defer func() {
+		if recover() != nil {
+			err = errors.New("Can't send on a closed channel.")
+		}
+	}()
+	lc.mu.Lock()
+	closed := lc.closed
+	lc.mu.Unlock()
+	if closed {
+		return errors.New("Can't send on a closed channel.")
+	}
+	lc.channel <- value
+	return nil
+}
+
+func (lc *LoxChannel) String() string {
+	return "<channel>"
+}
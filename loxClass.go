@@ -1,11 +1,19 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+)
 
+// abstract holds the names of the methods the class declares without a body.
+// A class with abstract methods can't be instantiated.
 type LoxClass struct {
 	name       string
 	methods    map[string]LoxFunction
+	abstract   []string
+	interfaces []*LoxInterface
 	superclass *LoxClass
+	id         int
 }
 
 func (lc LoxClass) findMethod(name string) (LoxFunction, error) {
@@ -21,24 +29,83 @@ func (lc LoxClass) findMethod(name string) (LoxFunction, error) {
 	}
 }
 
+func (lc LoxClass) isSubclassOf(other LoxClass) bool {
+	if isEqual(objectValue(lc), objectValue(other)) {
+		return true
+	}
+	if lc.superclass != nil {
+		return lc.superclass.isSubclassOf(other)
+	}
+	return false
+}
+
+// implements reports whether the class or one of its superclasses declares
+// that it implements iface.
+func (lc LoxClass) implements(iface *LoxInterface) bool {
+	if slices.Contains(lc.interfaces, iface) {
+		return true
+	}
+	if lc.superclass != nil {
+		return lc.superclass.implements(iface)
+	}
+	return false
+}
+
+// missingMethod finds an abstract or interface method that neither the class
+// nor its superclasses implement, along with the class or interface that
+// declares it.
+func (lc LoxClass) missingMethod() (string, string, bool) {
+	for klass := &lc; klass != nil; klass = klass.superclass {
+		for _, name := range klass.abstract {
+			if _, err := lc.findMethod(name); err != nil {
+				return name, klass.name, true
+			}
+		}
+		for _, iface := range klass.interfaces {
+			for _, name := range iface.methods {
+				if _, err := lc.findMethod(name); err != nil {
+					return name, iface.name, true
+				}
+			}
+		}
+	}
+	return "", "", false
+}
+
 func (lc LoxClass) String() string {
 	return lc.name
 }
 
-func (lc LoxClass) call(interp *Interpreter, arguments []any) any {
-	instance := LoxInstance{klass: lc, fields: make(map[string]any)}
+func (lc LoxClass) call(interp *Interpreter, arguments []Value) Value {
+	if len(lc.abstract) > 0 {
+		err := fmt.Errorf("Can't instantiate abstract class '%s'.", lc.name)
+		interp.lx.RuntimeError(interp.callSite, err)
+		interp.err = err
+		interp.badToken = interp.callSite
+		return Value{}
+	}
+	instance := newLoxInstance(lc)
 	initializer, err := lc.findMethod("init")
 	if err == nil { // user provided constructor
 		initializer.bind(instance).call(interp, arguments)
 	}
-	return instance
+	return objectValue(instance)
 }
 
-func (lc LoxClass) arity() int {
+func (lc LoxClass) arity() (int, int) {
 	intializer, err := lc.findMethod("init")
 	if err == nil {
 		return intializer.arity()
 	} else {
-		return 0
+		return 0, 0
+	}
+}
+
+func (lc LoxClass) parameters() []Param {
+	intializer, err := lc.findMethod("init")
+	if err == nil {
+		return intializer.parameters()
+	} else {
+		return nil
 	}
 }
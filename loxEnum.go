@@ -0,0 +1,51 @@
+package main
+
+import "fmt"
+
+type LoxEnum struct {
+	name    string
+	members []*LoxEnumMember
+}
+
+func (le *LoxEnum) get(name Token) (Value, error) {
+	for _, member := range le.members {
+		if member.name == name.lexeme {
+			return objectValue(member), nil
+		}
+	}
+	if name.lexeme == "values" {
+		return objectValue(&NativeFunction{name: "values", function: func(interp *Interpreter, args []Value) (Value, error) {
+			values := make([]Value, len(le.members))
+			for i, member := range le.members {
+				values[i] = objectValue(member)
+			}
+			return objectValue(&LoxList{elements: values}), nil
+		}}), nil
+	}
+	return Value{}, fmt.Errorf("Enum '%s' has no member '%s'.", le.name, name.lexeme)
+}
+
+func (le *LoxEnum) String() string {
+	return le.name
+}
+
+// Members are singletons, so they compare and hash by identity.
+type LoxEnumMember struct {
+	enum    *LoxEnum
+	name    string
+	ordinal int64
+}
+
+func (lm *LoxEnumMember) get(name Token) (Value, error) {
+	switch name.lexeme {
+	case "name":
+		return stringValue(lm.name), nil
+	case "ordinal":
+		return intValue(lm.ordinal), nil
+	}
+	return Value{}, fmt.Errorf("Undefined property '%s'.", name.lexeme)
+}
+
+func (lm *LoxEnumMember) String() string {
+	return lm.enum.name + "." + lm.name
+}
@@ -3,40 +3,255 @@ package main
 import (
 	"fmt"
 	"math/rand"
+	"reflect"
 )
 
+// receiver is the instance a method is bound to. A function returned by a
+// method decorator wasn't resolved as a method, so an extra scope for `this`
+// would throw off its variables. Instead, its receiver is defined alongside its
+// parameters and binds the method it wraps (see bindToReceiver).
 type LoxFunction struct {
 	declaration   Function
 	env           *Environment
 	isInitializer bool
+	isDecorated   bool
+	receiver      *LoxInstance
 }
 
 func (lf LoxFunction) bind(li LoxInstance) LoxFunction {
-	env := Environment{values: make(map[string]any), enclosing: lf.env, id: rand.Int()}
-	env.define("this", li)
-	return LoxFunction{declaration: lf.declaration, env: &env, isInitializer: lf.isInitializer}
+	if lf.isDecorated {
+		lf.receiver = &li
+		return lf
+	}
+	env := Environment{values: make(map[string]Value), enclosing: lf.env, id: rand.Int()}
+	env.define("this", objectValue(li))
+	return LoxFunction{declaration: lf.declaration, env: &env, isInitializer: lf.isInitializer, receiver: &li}
+}
+
+// closure is the environment the function was declared in, leaving out the
+// scope that binding a method adds for `this`.
+func (lf LoxFunction) closure() *Environment {
+	if lf.receiver != nil && !lf.isDecorated {
+		return lf.env.enclosing
+	}
+	return lf.env
 }
 
-func (lf LoxFunction) call(interp *Interpreter, args []any) any {
-	env := Environment{values: make(map[string]any), enclosing: lf.env, id: rand.Int()}
-	for i := range len(lf.declaration.params) {
-		env.define(lf.declaration.params[i].lexeme, args[i])
+// sameFunction reports whether two functions are the same declaration closed
+// over the same environment, and bound to the same instance if they are
+// methods, so that a.m == a.m.
+func sameFunction(left LoxFunction, right LoxFunction) bool {
+	if left.declaration.id != right.declaration.id || left.closure() != right.closure() {
+		return false
+	}
+	if left.receiver == nil || right.receiver == nil {
+		return left.receiver == right.receiver
 	}
-	interp.executeBlock(lf.declaration.body, &env)
+	return isEqual(objectValue(*left.receiver), objectValue(*right.receiver))
+}
+
+// hash agrees with sameFunction.
+func (lf LoxFunction) hash() int64 {
+	hash := int64(lf.declaration.id) ^ int64(reflect.ValueOf(lf.closure()).Pointer())
+	if lf.receiver != nil {
+		hash ^= hashValue(objectValue(*lf.receiver))
+	}
+	return hash
+}
+
+// noArgument fills the slot of a parameter that was skipped over by named
+// arguments, so that its default value is used instead.
+type noArgument struct{}
+
+// A tailCall is returned by `return f(x);`. The function that returns it makes
+// the call in place of its own body, so tail recursion runs in constant stack.
+type tailCall struct {
+	function  LoxCallable
+	arguments []Value
+	callSite  Token
+}
+
+func (lf LoxFunction) call(interp *Interpreter, args []Value) Value {
 	defer func() {
-		interp.returnVal = nil
+		interp.returnVal = Value{}
 		interp.checkReturn = false
 	}()
-	if lf.isInitializer {
-		output, _ := lf.env.getAt(0, "this")
-		return output
-	} else {
-		return interp.returnVal
+	for {
+		env, ok := lf.bindParameters(interp, args)
+		if !ok {
+			return Value{}
+		}
+		if lf.declaration.isGenerator {
+			return objectValue(newLoxGenerator(lf, env, interp))
+		}
+		interp.executeBlock(lf.declaration.body, env)
+		if len(env.deferred) > 0 {
+			return lf.returnDeferred(interp, env)
+		}
+		if interp.err != nil {
+			return Value{}
+		}
+		if lf.isInitializer {
+			output, _ := lf.env.getAt(0, "this")
+			return output
+		}
+		next, ok := interp.returnVal.ref.(tailCall)
+		if !ok {
+			return interp.returnVal
+		}
+		interp.returnVal = Value{}
+		interp.checkReturn = false
+		interp.callSite = next.callSite
+		function, ok := next.function.(LoxFunction)
+		if !ok {
+			return next.function.call(interp, next.arguments)
+		}
+		lf, args = function, next.arguments
+	}
+}
+
+// returnDeferred finishes a call whose frame has deferred calls. A call in tail
+// position is made before them rather than in place of the frame, so that the
+// deferred calls still run after it.
+func (lf LoxFunction) returnDeferred(interp *Interpreter, frame *Environment) Value {
+	var output Value
+	if interp.err == nil {
+		if lf.isInitializer {
+			output, _ = lf.env.getAt(0, "this")
+		} else if next, ok := interp.returnVal.ref.(tailCall); ok {
+			interp.returnVal = Value{}
+			interp.checkReturn = false
+			interp.callSite = next.callSite
+			output = next.function.call(interp, next.arguments)
+		} else {
+			output = interp.returnVal
+		}
+	}
+	runDeferred(interp, frame)
+	if interp.err != nil {
+		return Value{}
+	}
+	return output
+}
+
+type deferredCall struct {
+	function  LoxCallable
+	arguments []Value
+	callSite  Token
+}
+
+// runDeferred makes the deferred calls of frame, last deferred first. They all
+// run even if some fail, and the first error is the one that propagates.
+func runDeferred(interp *Interpreter, frame *Environment) {
+	err, badToken := interp.err, interp.badToken
+	for i := len(frame.deferred) - 1; i >= 0; i-- {
+		deferred := frame.deferred[i]
+		interp.err = nil
+		interp.callSite = deferred.callSite
+		deferred.function.call(interp, deferred.arguments)
+		if err == nil && interp.err != nil {
+			err, badToken = interp.err, interp.badToken
+		}
+	}
+	frame.deferred = nil
+	interp.err, interp.badToken = err, badToken
+}
+
+func (lf LoxFunction) bindParameters(interp *Interpreter, args []Value) (*Environment, bool) {
+	env := Environment{values: make(map[string]Value), enclosing: lf.env, id: rand.Int(), isFrame: true}
+	if lf.isDecorated && lf.receiver != nil {
+		env.define("this", objectValue(*lf.receiver))
+		env.receiver = lf.receiver
+	}
+	for i, param := range lf.declaration.params {
+		if param.variadic {
+			rest := make([]Value, 0)
+			if i < len(args) {
+				rest = append(rest, args[i:]...)
+			}
+			env.define(param.name.lexeme, objectValue(&LoxList{elements: rest}))
+		} else if i < len(args) && args[i].ref != (noArgument{}) {
+			env.define(param.name.lexeme, args[i])
+		} else {
+			// Defaults are evaluated at call time so they can refer to earlier parameters
+			value, err, badToken := evalExpr(param.defaultValue, &env, interp.locals, interp.lx)
+			if err != nil {
+				interp.err = err
+				interp.badToken = badToken
+				return nil, false
+			}
+			env.define(param.name.lexeme, value)
+		}
+	}
+	return &env, true
+}
+
+// An unboundMethod is what method decorators receive. It can't be called as it
+// is, since it has no instance, but the wrapper that a decorator returns gets it
+// bound by referring to it.
+type unboundMethod struct {
+	method LoxFunction
+}
+
+func (um unboundMethod) call(interp *Interpreter, args []Value) Value {
+	err := fmt.Errorf("Can only call method '%s' on an instance.", um.method.declaration.name.lexeme)
+	interp.lx.RuntimeError(interp.callSite, err)
+	interp.err = err
+	interp.badToken = interp.callSite
+	return Value{}
+}
+
+// bindToReceiver binds a method that is waiting for an instance, when code
+// in the call of a bound decorated method refers to it by name. It is bound to
+// the instance that the decorated method was bound to, so it can be passed
+// around like any other bound method. Other values are returned as they are.
+func bindToReceiver(val Value, env *Environment) Value {
+	if val.kind != OBJECT_KIND {
+		return val
+	}
+	switch method := val.ref.(type) {
+	case unboundMethod:
+		if receiver := env.enclosingReceiver(); receiver != nil {
+			return objectValue(method.method.bind(*receiver))
+		}
+	case LoxFunction:
+		// The wrapper of an inner decorator, when decorators are stacked
+		if method.isDecorated && method.receiver == nil {
+			if receiver := env.enclosingReceiver(); receiver != nil {
+				return objectValue(method.bind(*receiver))
+			}
+		}
+	}
+	return val
+}
+
+func (um unboundMethod) arity() (int, int) {
+	return um.method.arity()
+}
+
+func (um unboundMethod) parameters() []Param {
+	return um.method.parameters()
+}
+
+func (um unboundMethod) String() string {
+	return um.method.String()
+}
+
+func (lf LoxFunction) arity() (int, int) {
+	min := 0
+	for _, param := range lf.declaration.params {
+		if param.variadic {
+			return min, VARIADIC
+		}
+		if param.defaultValue == nil {
+			min += 1
+		}
 	}
+	return min, len(lf.declaration.params)
 }
 
-func (lf LoxFunction) arity() int {
-	return len(lf.declaration.params)
+func (lf LoxFunction) parameters() []Param {
+	return lf.declaration.params
 }
 
 func (lf LoxFunction) String() string {
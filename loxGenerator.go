@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// A LoxGenerator runs the body of a generator function on its own goroutine,
+// which starts the first time a value is asked for. Only one of the generator
+// and its caller runs at a time: the caller blocks on values while the body
+// runs, and the body blocks on resume after each yield.
+type LoxGenerator struct {
+	function LoxFunction
+	env      *Environment
+	locals   map[int]int
+	lx       *Lox
+	body     *generatorBody
+	value    Value
+	hasValue bool
+	finished bool
+}
+
+// generatorBody is the part of a generator that its goroutine uses. The
+// goroutine never refers to the LoxGenerator, so an abandoned generator can be
+// garbage collected, and its finalizer closes it. Generators can't defer calls,
+// so closing one runs no Lox code.
+type generatorBody struct {
+	resume   chan struct{}
+	values   chan Value
+	closed   bool
+	err      error
+	badToken Token
+}
+
+// errGeneratorClosed unwinds the body of a generator that was closed while it
+// was waiting at a yield. It is never reported.
+var errGeneratorClosed = errors.New("Generator is closed.")
+
+func newLoxGenerator(function LoxFunction, env *Environment, interp *Interpreter) *LoxGenerator {
+	return &LoxGenerator{function: function, env: env, locals: interp.locals, lx: interp.lx}
+}
+
+func (lg *LoxGenerator) start() {
+	body := &generatorBody{resume: make(chan struct{}), values: make(chan Value)}
+	lg.body = body
+	lg.env.generator = body
+	function, env, locals, lx := lg.function, lg.env, lg.locals, lg.lx
+	go func() {
+		interp := &Interpreter{env: env, locals: locals, lx: lx}
+		interp.executeBlock(function.declaration.body, env)
+		if interp.err == errGeneratorClosed {
+			interp.err = nil
+		}
+		body.err = interp.err
+		body.badToken = interp.badToken
+		close(body.values)
+	}()
+	runtime.SetFinalizer(lg, func(lg *LoxGenerator) {
+		if !lg.finished {
+			close(lg.body.resume)
+		}
+	})
+}
+
+// yield is called from the generator's goroutine. Once the generator is
+// closed, it returns errGeneratorClosed so that the body stops.
+func (gb *generatorBody) yield(value Value) error {
+	if gb.closed {
+		return errGeneratorClosed
+	}
+	gb.values <- value
+	if _, ok := <-gb.resume; !ok {
+		gb.closed = true
+		return errGeneratorClosed
+	}
+	return nil
+}
+
+// advance runs the body up to its next yield, unless a value is already
+// waiting. It reports whether there is a value to take.
+func (lg *LoxGenerator) advance(interp *Interpreter) bool {
+	if lg.hasValue {
+		return true
+	}
+	if lg.finished {
+		return false
+	}
+	if lg.body == nil {
+		lg.start()
+	} else {
+		lg.body.resume <- struct{}{}
+	}
+	value, ok := <-lg.body.values
+	if !ok {
+		lg.finished = true
+		lg.reportError(interp)
+		return false
+	}
+	lg.value = value
+	lg.hasValue = true
+	return true
+}
+
+// close stops the body at the yield it is waiting at. A generator that never
+// started has nothing to clean up.
+func (lg *LoxGenerator) close(interp *Interpreter) {
+	if lg.finished {
+		return
+	}
+	lg.finished = true
+	lg.hasValue = false
+	if lg.body == nil {
+		return
+	}
+	close(lg.body.resume)
+	for range lg.body.values {
+	}
+	lg.reportError(interp)
+}
+
+func (lg *LoxGenerator) reportError(interp *Interpreter) {
+	if lg.body.err != nil {
+		// Already reported where it happened in the body
+		interp.err = lg.body.err
+		interp.badToken = lg.body.badToken
+	}
+}
+
+func (lg *LoxGenerator) get(name Token) (Value, error) {
+	switch name.lexeme {
+	case "hasNext":
+		return objectValue(&NativeFunction{name: "hasNext", function: func(interp *Interpreter, args []Value) (Value, error) {
+			return boolValue(lg.advance(interp)), nil
+		}}), nil
+	case "next":
+		return objectValue(&NativeFunction{name: "next", function: func(interp *Interpreter, args []Value) (Value, error) {
+			if !lg.advance(interp) {
+				if interp.err != nil {
+					return Value{}, nil
+				}
+				return Value{}, errors.New("Generator is exhausted.")
+			}
+			lg.hasValue = false
+			return lg.value, nil
+		}}), nil
+	case "close":
+		return objectValue(&NativeFunction{name: "close", function: func(interp *Interpreter, args []Value) (Value, error) {
+			lg.close(interp)
+			return Value{}, nil
+		}}), nil
+	}
+	return Value{}, fmt.Errorf("Undefined property '%s'.", name.lexeme)
+}
+
+func (lg *LoxGenerator) String() string {
+	return fmt.Sprintf("<generator %s>", lg.function.declaration.name.lexeme)
+}
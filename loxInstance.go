@@ -1,25 +1,126 @@
 package main
 
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Instances can be shared by spawned goroutines, so every access to fields
+// holds mu. Copies of an instance share both.
 type LoxInstance struct {
 	klass  LoxClass
-	fields map[string]any
+	fields map[string]Value
+	mu     *sync.RWMutex
+}
+
+func newLoxInstance(klass LoxClass) LoxInstance {
+	return LoxInstance{klass: klass, fields: make(map[string]Value), mu: &sync.RWMutex{}}
+}
+
+func (li LoxInstance) field(name string) (Value, bool) {
+	li.mu.RLock()
+	defer li.mu.RUnlock()
+	val, ok := li.fields[name]
+	return val, ok
+}
+
+// fieldNames lists the names of the fields, in no particular order.
+func (li LoxInstance) fieldNames() []string {
+	li.mu.RLock()
+	defer li.mu.RUnlock()
+	names := make([]string, 0, len(li.fields))
+	for name := range li.fields {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (li LoxInstance) get(name Token) (Value, error) {
+	return li.getFrom(name, 0)
 }
 
-func (li LoxInstance) get(name Token) (any, error) {
-	val, ok := li.fields[name.lexeme]
+// getFrom reads a property on behalf of code written in the class with id
+// classId.
+func (li LoxInstance) getFrom(name Token, classId int) (Value, error) {
+	if isPrivate(name) {
+		return li.getPrivate(name, classId)
+	}
+	val, ok := li.field(name.lexeme)
 	if ok {
 		return val, nil
 	}
 	method, methodErr := li.klass.findMethod(name.lexeme)
 	if methodErr != nil {
-		return nil, methodErr
+		return Value{}, methodErr
 	} else {
-		return method.bind(li), nil
+		return objectValue(method.bind(li)), nil
+	}
+}
+
+func (li LoxInstance) set(name Token, value Value) error {
+	return li.setFrom(name, value, 0)
+}
+
+func (li LoxInstance) setFrom(name Token, value Value, classId int) error {
+	key := name.lexeme
+	if isPrivate(name) {
+		if li.declaringClass(classId) == nil {
+			return privateAccessError(name)
+		}
+		key = privateKey(name.lexeme, classId)
+	}
+	li.mu.Lock()
+	li.fields[key] = value
+	li.mu.Unlock()
+	return nil
+}
+
+// Private members can only be accessed from the class that declares them, so
+// a subclass can neither read nor overwrite those of its superclasses. Each
+// class keeps its private fields under its own key, made by privateKey.
+
+func isPrivate(name Token) bool {
+	return strings.HasPrefix(name.lexeme, "#")
+}
+
+func privateKey(name string, classId int) string {
+	return fmt.Sprintf("%s@%d", name, classId)
+}
+
+func privateAccessError(name Token) error {
+	return fmt.Errorf("Can't access private member '%s' outside of its class.", name.lexeme)
+}
+
+// declaringClass finds the class with id classId among the instance's class
+// and its superclasses.
+func (li LoxInstance) declaringClass(classId int) *LoxClass {
+	for klass := &li.klass; klass != nil; klass = klass.superclass {
+		if classId != 0 && klass.id == classId {
+			return klass
+		}
 	}
+	return nil
 }
 
-func (li LoxInstance) set(name Token, value any) {
-	li.fields[name.lexeme] = value
+func (li LoxInstance) getPrivate(name Token, classId int) (Value, error) {
+	if klass := li.declaringClass(classId); klass != nil {
+		if val, ok := li.field(privateKey(name.lexeme, classId)); ok {
+			return val, nil
+		}
+		if method, ok := klass.methods[name.lexeme]; ok {
+			return objectValue(method.bind(li)), nil
+		}
+	}
+	// Tell apart members of other classes from ones that don't exist
+	for klass := &li.klass; klass != nil; klass = klass.superclass {
+		_, isField := li.field(privateKey(name.lexeme, klass.id))
+		_, isMethod := klass.methods[name.lexeme]
+		if isField || isMethod {
+			return Value{}, privateAccessError(name)
+		}
+	}
+	return Value{}, fmt.Errorf("Undefined property '%s'.", name.lexeme)
 }
 
 func (li LoxInstance) String() string {
@@ -0,0 +1,26 @@
+package main
+
+import "fmt"
+
+type LoxInterface struct {
+	name    string
+	methods []string
+}
+
+func (li *LoxInterface) String() string {
+	return li.name
+}
+
+// isInstance checks whether value is an instance of a class, or of a class
+// implementing an interface.
+func isInstance(value Value, typ Value) (bool, error) {
+	instance, isObject := value.ref.(LoxInstance)
+	switch t := typ.ref.(type) {
+	case LoxClass:
+		return isObject && instance.klass.isSubclassOf(t), nil
+	case *LoxInterface:
+		return isObject && instance.klass.implements(t), nil
+	default:
+		return false, fmt.Errorf("Right operand of 'is' must be a class or interface.")
+	}
+}
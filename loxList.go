@@ -0,0 +1,15 @@
+package main
+
+import "strings"
+
+type LoxList struct {
+	elements []Value
+}
+
+func (ll *LoxList) String() string {
+	elements := make([]string, len(ll.elements))
+	for i, element := range ll.elements {
+		elements[i] = element.String()
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
@@ -0,0 +1,7 @@
+package main
+
+// LoxObject is implemented by every value that has properties, so that
+// visitGet can read them.
+type LoxObject interface {
+	get(name Token) (Value, error)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// A LoxRange is a lazy sequence of integers from start to end, counting by
+// step. The end is only part of the range if it is inclusive.
+type LoxRange struct {
+	start     int64
+	end       int64
+	step      int64
+	inclusive bool
+}
+
+func newLoxRange(start Value, end Value, inclusive bool) (*LoxRange, error) {
+	if start.kind != INT_KIND || end.kind != INT_KIND {
+		return nil, errors.New("Range bounds must be integers.")
+	}
+	return &LoxRange{start: start.asInt(), end: end.asInt(), step: 1, inclusive: inclusive}, nil
+}
+
+// last is the furthest value the range may reach in the direction of its step.
+// It reports false if the range is empty.
+func (lr *LoxRange) last() (int64, bool) {
+	switch {
+	case lr.inclusive && lr.step > 0:
+		return lr.end, lr.start <= lr.end
+	case lr.inclusive:
+		return lr.end, lr.start >= lr.end
+	case lr.step > 0:
+		return lr.end - 1, lr.start < lr.end
+	default:
+		return lr.end + 1, lr.start > lr.end
+	}
+}
+
+// Distances within a range are unsigned, since they can be larger than the
+// largest int64. The arithmetic wraps around, which still gives the right
+// distance as long as it is between values of the range.
+
+func (lr *LoxRange) stride() uint64 {
+	if lr.step > 0 {
+		return uint64(lr.step)
+	}
+	return -uint64(lr.step)
+}
+
+// distance is how far value is from the start, in the direction of the step.
+func (lr *LoxRange) distance(value int64) uint64 {
+	if lr.step > 0 {
+		return uint64(value) - uint64(lr.start)
+	}
+	return uint64(lr.start) - uint64(value)
+}
+
+// length is capped at the largest int64, which only the very longest ranges
+// exceed.
+func (lr *LoxRange) length() int64 {
+	last, ok := lr.last()
+	if !ok {
+		return 0
+	}
+	steps := lr.distance(last) / lr.stride()
+	if steps >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(steps) + 1
+}
+
+func (lr *LoxRange) at(i int64) int64 {
+	return int64(uint64(lr.start) + uint64(i)*uint64(lr.step))
+}
+
+func (lr *LoxRange) contains(value Value) bool {
+	if value.kind != INT_KIND {
+		return false
+	}
+	last, ok := lr.last()
+	v := value.asInt()
+	if !ok || lr.step > 0 && (v < lr.start || v > last) || lr.step < 0 && (v > lr.start || v < last) {
+		return false
+	}
+	return lr.distance(v)%lr.stride() == 0
+}
+
+// indices checks that every value of the range is a position in a sequence of
+// the given length, for slicing it. Since the values only go one way, checking
+// the first and the last one is enough.
+func (lr *LoxRange) indices(length int) ([]int, error) {
+	count := lr.length()
+	if count == 0 {
+		return []int{}, nil
+	}
+	for _, index := range []int64{lr.start, lr.at(count - 1)} {
+		if index < 0 || index >= int64(length) {
+			return nil, errors.New("Index out of range.")
+		}
+	}
+	indices := make([]int, count)
+	for i := range indices {
+		indices[i] = int(lr.at(int64(i)))
+	}
+	return indices, nil
+}
+
+func (lr *LoxRange) get(name Token) (Value, error) {
+	switch name.lexeme {
+	case "start":
+		return intValue(lr.start), nil
+	case "end":
+		return intValue(lr.end), nil
+	case "length":
+		return intValue(lr.length()), nil
+	case "step":
+		return objectValue(&NativeFunction{name: "step", minArity: 1, maxArity: 1, function: func(interp *Interpreter, args []Value) (Value, error) {
+			if args[0].kind != INT_KIND {
+				return Value{}, errors.New("Range step must be an integer.")
+			}
+			if args[0].asInt() == 0 {
+				return Value{}, errors.New("Range step can't be zero.")
+			}
+			return objectValue(&LoxRange{start: lr.start, end: lr.end, step: args[0].asInt(), inclusive: lr.inclusive}), nil
+		}}), nil
+	case "contains":
+		return objectValue(&NativeFunction{name: "contains", minArity: 1, maxArity: 1, function: func(interp *Interpreter, args []Value) (Value, error) {
+			return boolValue(lr.contains(args[0])), nil
+		}}), nil
+	}
+	return Value{}, fmt.Errorf("Undefined property '%s'.", name.lexeme)
+}
+
+func (lr *LoxRange) String() string {
+	operator := "..<"
+	if lr.inclusive {
+		operator = ".."
+	}
+	text := fmt.Sprintf("%d%s%d", lr.start, operator, lr.end)
+	if lr.step != 1 {
+		text += fmt.Sprintf(" step %d", lr.step)
+	}
+	return text
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// stringMethods make up the prototype shared by all strings. Positions count
+// characters rather than bytes.
+var stringMethods = map[string]*NativeFunction{
+	"substring": {name: "substring", minArity: 1, maxArity: 2, function: func(interp *Interpreter, args []Value) (Value, error) {
+		runes := []rune(args[0].asString())
+		start, err := toIndex(args[1], len(runes)+1)
+		if err != nil {
+			return Value{}, err
+		}
+		end := len(runes)
+		if len(args) == 3 {
+			if end, err = toIndex(args[2], len(runes)+1); err != nil {
+				return Value{}, err
+			}
+		}
+		if end < start {
+			return Value{}, errors.New("Substring end can't come before its start.")
+		}
+		return stringValue(string(runes[start:end])), nil
+	}},
+	"indexOf": {name: "indexOf", minArity: 1, maxArity: 1, function: func(interp *Interpreter, args []Value) (Value, error) {
+		s := args[0].asString()
+		substring, err := stringArgument(args[1])
+		if err != nil {
+			return Value{}, err
+		}
+		index := strings.Index(s, substring)
+		if index < 0 {
+			return intValue(-1), nil
+		}
+		return intValue(int64(utf8.RuneCountInString(s[:index]))), nil
+	}},
+	"split": {name: "split", minArity: 1, maxArity: 1, function: func(interp *Interpreter, args []Value) (Value, error) {
+		separator, err := stringArgument(args[1])
+		if err != nil {
+			return Value{}, err
+		}
+		return objectValue(stringList(strings.Split(args[0].asString(), separator))), nil
+	}},
+	"trim": {name: "trim", function: func(interp *Interpreter, args []Value) (Value, error) {
+		return stringValue(strings.TrimSpace(args[0].asString())), nil
+	}},
+	"upper": {name: "upper", function: func(interp *Interpreter, args []Value) (Value, error) {
+		return stringValue(strings.ToUpper(args[0].asString())), nil
+	}},
+	"lower": {name: "lower", function: func(interp *Interpreter, args []Value) (Value, error) {
+		return stringValue(strings.ToLower(args[0].asString())), nil
+	}},
+	"replace": {name: "replace", minArity: 2, maxArity: 2, function: func(interp *Interpreter, args []Value) (Value, error) {
+		old, err := stringArgument(args[1])
+		if err != nil {
+			return Value{}, err
+		}
+		replacement, err := stringArgument(args[2])
+		if err != nil {
+			return Value{}, err
+		}
+		return stringValue(strings.ReplaceAll(args[0].asString(), old, replacement)), nil
+	}},
+	"startsWith": {name: "startsWith", minArity: 1, maxArity: 1, function: func(interp *Interpreter, args []Value) (Value, error) {
+		prefix, err := stringArgument(args[1])
+		if err != nil {
+			return Value{}, err
+		}
+		return boolValue(strings.HasPrefix(args[0].asString(), prefix)), nil
+	}},
+	"chars": {name: "chars", function: func(interp *Interpreter, args []Value) (Value, error) {
+		return objectValue(stringList(strings.Split(args[0].asString(), ""))), nil
+	}},
+}
+
+// stringProperty looks up name on s. Methods are bound by passing s ahead of
+// the caller's arguments.
+func stringProperty(s Value, name Token) (Value, error) {
+	if name.lexeme == "length" {
+		return intValue(int64(utf8.RuneCountInString(s.asString()))), nil
+	}
+	method, ok := stringMethods[name.lexeme]
+	if !ok {
+		return Value{}, fmt.Errorf("Undefined property '%s'.", name.lexeme)
+	}
+	return objectValue(&NativeFunction{name: method.name, minArity: method.minArity, maxArity: method.maxArity, function: func(interp *Interpreter, args []Value) (Value, error) {
+		return method.function(interp, append([]Value{s}, args...))
+	}}), nil
+}
+
+func stringArgument(value Value) (string, error) {
+	if !value.isString() {
+		return "", errors.New("Argument must be a string.")
+	}
+	return value.asString(), nil
+}
+
+func stringList(strs []string) *LoxList {
+	elements := make([]Value, len(strs))
+	for i, s := range strs {
+		elements[i] = stringValue(s)
+	}
+	return &LoxList{elements: elements}
+}
+
+// toIndex checks that value is an integer position in a sequence of the given
+// length.
+func toIndex(value Value, length int) (int, error) {
+	if value.kind == BIG_INT_KIND {
+		// Too big to be a position in anything that fits in memory
+		return 0, errors.New("Index out of range.")
+	}
+	if value.kind != INT_KIND {
+		return 0, errors.New("Index must be an integer.")
+	}
+	index := value.asInt()
+	if index < 0 || index >= int64(length) {
+		return 0, errors.New("Index out of range.")
+	}
+	return int(index), nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+type LoxWaitGroup struct {
+	waitGroup sync.WaitGroup
+	mu        sync.Mutex
+	count     int64
+}
+
+var waitGroupClass = &NativeClass{
+	name: "WaitGroup",
+	constructor: NativeFunction{name: "WaitGroup", function: func(interp *Interpreter, args []Value) (Value, error) {
+		return objectValue(&LoxWaitGroup{}), nil
+	}},
+}
+
+func (lw *LoxWaitGroup) get(name Token) (Value, error) {
+	switch name.lexeme {
+	case "add":
+		return objectValue(&NativeFunction{name: "add", minArity: 0, maxArity: 1, function: func(interp *Interpreter, args []Value) (Value, error) {
+			delta := int64(1)
+			if len(args) == 1 {
+				if args[0].kind != INT_KIND {
+					return Value{}, errors.New("Wait group delta must be an integer.")
+				}
+				delta = args[0].asInt()
+			}
+			return Value{}, lw.add(delta)
+		}}), nil
+	case "done":
+		return objectValue(&NativeFunction{name: "done", function: func(interp *Interpreter, args []Value) (Value, error) {
+			return Value{}, lw.add(-1)
+		}}), nil
+	case "wait":
+		return objectValue(&NativeFunction{name: "wait", function: func(interp *Interpreter, args []Value) (Value, error) {
+			lw.waitGroup.Wait()
+			return Value{}, nil
+		}}), nil
+	}
+	return Value{}, fmt.Errorf("Undefined property '%s'.", name.lexeme)
+}
+
+// add keeps its own count so that a negative counter is a runtime error
+// instead of a panic.
+func (lw *LoxWaitGroup) add(delta int64) error {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if lw.count+delta < 0 {
+		return errors.New("Wait group counter can't go below zero.")
+	}
+	lw.count += delta
+	lw.waitGroup.Add(int(delta))
+	return nil
+}
+
+func (lw *LoxWaitGroup) String() string {
+	return "<wait group>"
+}
@@ -0,0 +1,31 @@
+package main
+
+import "fmt"
+
+// A NativeClass is a built-in class implemented in Go. Calling it runs its
+// constructor, and its static methods are read as properties.
+type NativeClass struct {
+	name        string
+	constructor NativeFunction
+	statics     map[string]*NativeFunction
+}
+
+func (nc *NativeClass) arity() (int, int) {
+	return nc.constructor.arity()
+}
+
+func (nc *NativeClass) call(interp *Interpreter, args []Value) Value {
+	return nc.constructor.call(interp, args)
+}
+
+func (nc *NativeClass) get(name Token) (Value, error) {
+	method, ok := nc.statics[name.lexeme]
+	if !ok {
+		return Value{}, fmt.Errorf("Undefined property '%s'.", name.lexeme)
+	}
+	return objectValue(method), nil
+}
+
+func (nc *NativeClass) String() string {
+	return nc.name
+}
@@ -0,0 +1,30 @@
+package main
+
+// NativeFunction wraps a Go function so it can be called from Lox. Errors it
+// returns are reported at the call site. Natives are passed around by pointer,
+// which is what they are compared and hashed by.
+type NativeFunction struct {
+	name     string
+	minArity int
+	maxArity int
+	function func(interp *Interpreter, args []Value) (Value, error)
+}
+
+func (nf NativeFunction) arity() (int, int) {
+	return nf.minArity, nf.maxArity
+}
+
+func (nf NativeFunction) call(interp *Interpreter, args []Value) Value {
+	output, err := nf.function(interp, args)
+	if err != nil {
+		interp.lx.RuntimeError(interp.callSite, err)
+		interp.err = err
+		interp.badToken = interp.callSite
+		return Value{}
+	}
+	return output
+}
+
+func (nf NativeFunction) String() string {
+	return "<native fn>"
+}
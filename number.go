@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"math/big"
+)
+
+// Numbers are exact int64s that get promoted to *big.Int when they overflow,
+// and demoted again once they fit. float64 is only used for literals with a
+// fractional part and for results that can't be represented as integers.
+
+func toBigInt(val Value) *big.Int {
+	switch val.kind {
+	case INT_KIND:
+		return big.NewInt(val.asInt())
+	case BIG_INT_KIND:
+		return val.asBigInt()
+	}
+	return nil
+}
+
+func toBigFloat(val Value) *big.Float {
+	switch val.kind {
+	case INT_KIND:
+		return new(big.Float).SetInt64(val.asInt())
+	case BIG_INT_KIND:
+		return new(big.Float).SetInt(val.asBigInt())
+	case FLOAT_KIND:
+		return big.NewFloat(val.asFloat())
+	}
+	return nil
+}
+
+func toFloat(val Value) (float64, error) {
+	switch val.kind {
+	case INT_KIND:
+		return float64(val.asInt()), nil
+	case BIG_INT_KIND:
+		f, _ := new(big.Float).SetInt(val.asBigInt()).Float64()
+		return f, nil
+	case FLOAT_KIND:
+		return val.asFloat(), nil
+	}
+	return 0, errors.New("Operand must be a number.")
+}
+
+func arithmetic(operator TokenType, left Value, right Value) (Value, error) {
+	if left.kind == INT_KIND && right.kind == INT_KIND {
+		if result, ok := intArithmetic(operator, left.asInt(), right.asInt()); ok {
+			return result, nil
+		}
+		return bigArithmetic(operator, toBigInt(left), toBigInt(right))
+	}
+	if !left.isNumber() || !right.isNumber() {
+		return Value{}, errors.New("Operands must be numbers.")
+	}
+	if left.kind == FLOAT_KIND || right.kind == FLOAT_KIND {
+		leftVal, _ := toFloat(left)
+		rightVal, _ := toFloat(right)
+		return floatArithmetic(operator, leftVal, rightVal)
+	}
+	return bigArithmetic(operator, toBigInt(left), toBigInt(right))
+}
+
+func floatArithmetic(operator TokenType, left float64, right float64) (Value, error) {
+	switch operator {
+	case PLUS:
+		return floatValue(left + right), nil
+	case MINUS:
+		return floatValue(left - right), nil
+	case STAR:
+		return floatValue(left * right), nil
+	case SLASH:
+		if right == 0 {
+			return Value{}, errors.New("Dividing by zero")
+		}
+		return floatValue(left / right), nil
+	}
+	return Value{}, errors.New("Unknown arithmetic operator.")
+}
+
+// intArithmetic returns false when the result doesn't fit in an int64 or
+// isn't an integer, leaving bigArithmetic to handle it.
+func intArithmetic(operator TokenType, left int64, right int64) (Value, bool) {
+	switch operator {
+	case PLUS:
+		sum := left + right
+		if (left > 0 && right > 0 && sum < 0) || (left < 0 && right < 0 && sum >= 0) {
+			return Value{}, false
+		}
+		return intValue(sum), true
+	case MINUS:
+		difference := left - right
+		if (left >= 0 && right < 0 && difference < 0) || (left < 0 && right > 0 && difference >= 0) {
+			return Value{}, false
+		}
+		return intValue(difference), true
+	case STAR:
+		if left == 0 || right == 0 {
+			return intValue(0), true
+		}
+		product := left * right
+		if product/right != left || (left == -1 && right == math.MinInt64) || (right == -1 && left == math.MinInt64) {
+			return Value{}, false
+		}
+		return intValue(product), true
+	case SLASH:
+		if right == 0 || (left == math.MinInt64 && right == -1) || left%right != 0 {
+			return Value{}, false
+		}
+		return intValue(left / right), true
+	}
+	return Value{}, false
+}
+
+func bigArithmetic(operator TokenType, left *big.Int, right *big.Int) (Value, error) {
+	switch operator {
+	case PLUS:
+		return bigValue(new(big.Int).Add(left, right)), nil
+	case MINUS:
+		return bigValue(new(big.Int).Sub(left, right)), nil
+	case STAR:
+		return bigValue(new(big.Int).Mul(left, right)), nil
+	case SLASH:
+		if right.Sign() == 0 {
+			return Value{}, errors.New("Dividing by zero")
+		}
+		quotient, remainder := new(big.Int).QuoRem(left, right, new(big.Int))
+		if remainder.Sign() == 0 {
+			return bigValue(quotient), nil
+		}
+		output, _ := new(big.Float).Quo(new(big.Float).SetInt(left), new(big.Float).SetInt(right)).Float64()
+		return floatValue(output), nil
+	}
+	return Value{}, errors.New("Unknown arithmetic operator.")
+}
+
+func negate(val Value) (Value, error) {
+	switch val.kind {
+	case INT_KIND:
+		if val.asInt() == math.MinInt64 {
+			return bigValue(new(big.Int).Neg(big.NewInt(val.asInt()))), nil
+		}
+		return intValue(-val.asInt()), nil
+	case BIG_INT_KIND:
+		return bigValue(new(big.Int).Neg(val.asBigInt())), nil
+	case FLOAT_KIND:
+		return floatValue(-val.asFloat()), nil
+	}
+	return Value{}, errors.New("Operand must be a number.")
+}
+
+// compareNumbers returns -1, 0 or 1 like big.Int.Cmp. It returns false if the
+// numbers are unordered because one of them is NaN.
+func compareNumbers(left Value, right Value) (int, bool) {
+	if left.kind == INT_KIND && right.kind == INT_KIND {
+		switch leftInt, rightInt := left.asInt(), right.asInt(); {
+		case leftInt < rightInt:
+			return -1, true
+		case leftInt > rightInt:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+	leftIsFloat := left.kind == FLOAT_KIND
+	rightIsFloat := right.kind == FLOAT_KIND
+	if (leftIsFloat && math.IsNaN(left.asFloat())) || (rightIsFloat && math.IsNaN(right.asFloat())) {
+		return 0, false
+	}
+	if !leftIsFloat && !rightIsFloat {
+		return toBigInt(left).Cmp(toBigInt(right)), true
+	}
+	return toBigFloat(left).Cmp(toBigFloat(right)), true
+}
+
+func compare(operator TokenType, left Value, right Value) (bool, error) {
+	if !left.isNumber() || !right.isNumber() {
+		return false, errors.New("Operands must be numbers.")
+	}
+	cmp, ordered := compareNumbers(left, right)
+	if !ordered {
+		return false, nil
+	}
+	switch operator {
+	case GREATER:
+		return cmp > 0, nil
+	case GREATER_EQUAL:
+		return cmp >= 0, nil
+	case LESS:
+		return cmp < 0, nil
+	case LESS_EQUAL:
+		return cmp <= 0, nil
+	}
+	return false, errors.New("Unknown comparison operator.")
+}
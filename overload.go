@@ -0,0 +1,69 @@
+package main
+
+// Classes can overload operators by defining these methods. When the left
+// operand doesn't handle a binary operator, the reflected method of the right
+// operand is tried with the operands swapped. Equality isn't dispatched here:
+// __eq__ is another name for the equals method, so that == has a single hook
+// for hash to agree with.
+var operatorMethods = map[TokenType]string{
+	PLUS:          "__add__",
+	MINUS:         "__sub__",
+	STAR:          "__mul__",
+	SLASH:         "__div__",
+	LESS:          "__lt__",
+	LESS_EQUAL:    "__le__",
+	GREATER:       "__gt__",
+	GREATER_EQUAL: "__ge__",
+}
+
+var reflectedOperatorMethods = map[TokenType]string{
+	PLUS:          "__radd__",
+	MINUS:         "__rsub__",
+	STAR:          "__rmul__",
+	SLASH:         "__rdiv__",
+	LESS:          "__gt__",
+	LESS_EQUAL:    "__ge__",
+	GREATER:       "__lt__",
+	GREATER_EQUAL: "__le__",
+}
+
+// binaryOverload calls the method overloading operator on left or right, if
+// either defines one. It reports whether a method was called.
+func (interp *Interpreter) binaryOverload(operator Token, left Value, right Value) bool {
+	if _, ok := operatorMethods[operator.tokenType]; !ok {
+		return false
+	}
+	if interp.callOperatorMethod(left, operatorMethods[operator.tokenType], right, operator) {
+		return true
+	}
+	if interp.callOperatorMethod(right, reflectedOperatorMethods[operator.tokenType], left, operator) {
+		return true
+	}
+	return false
+}
+
+func (interp *Interpreter) unaryOverload(operator Token, right Value) bool {
+	if operator.tokenType != MINUS {
+		return false
+	}
+	return interp.callOperatorMethod(right, "__neg__", Value{}, operator)
+}
+
+// callOperatorMethod calls the method called name on receiver, passing other
+// unless it is a unary operator.
+func (interp *Interpreter) callOperatorMethod(receiver Value, name string, other Value, operator Token) bool {
+	instance, ok := receiver.ref.(LoxInstance)
+	if !ok {
+		return false
+	}
+	method, methodErr := instance.klass.findMethod(name)
+	if methodErr != nil {
+		return false
+	}
+	arguments := []Value{other}
+	if name == "__neg__" {
+		arguments = []Value{}
+	}
+	interp.output, _ = interp.callMethod(instance, method, arguments, operator)
+	return true
+}
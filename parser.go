@@ -9,10 +9,13 @@ import (
 // --------------- PARSER ---------------
 
 type Parser struct {
+	source    string
 	tokens    []Token
 	current   int
 	lx        *Lox
 	idCounter int
+	sawYield  bool
+	classId   int
 }
 
 func (p *Parser) Parse() ([]Stmt, error) {
@@ -35,6 +38,15 @@ func (p *Parser) getId() int {
 // --------------- STATEMENTS ---------------
 
 func (p *Parser) declaration() (Stmt, error) {
+	if p.check(AT) {
+		decorated, err := p.decoratedDeclaration()
+		if err != nil {
+			p.synchronize()
+			return nil, nil
+		} else {
+			return decorated, nil
+		}
+	}
 	if p.match([]TokenType{CLASS}) {
 		class, err := p.class()
 		if err != nil {
@@ -44,6 +56,24 @@ func (p *Parser) declaration() (Stmt, error) {
 			return class, nil
 		}
 	}
+	if p.match([]TokenType{INTERFACE}) {
+		iface, err := p.interfaceDeclaration()
+		if err != nil {
+			p.synchronize()
+			return nil, nil
+		} else {
+			return iface, nil
+		}
+	}
+	if p.match([]TokenType{ENUM}) {
+		enum, err := p.enum()
+		if err != nil {
+			p.synchronize()
+			return nil, nil
+		} else {
+			return enum, nil
+		}
+	}
 	if p.match([]TokenType{FUN}) {
 		function, err := p.function("function")
 		if err != nil {
@@ -71,22 +101,144 @@ func (p *Parser) declaration() (Stmt, error) {
 	}
 }
 
-func (p *Parser) class() (Stmt, error) {
+func (p *Parser) decoratedDeclaration() (Stmt, error) {
+	decorators, err := p.decorators()
+	if err != nil {
+		return nil, err
+	}
+	if p.match([]TokenType{CLASS}) {
+		class, err := p.class()
+		class.decorators = decorators
+		return class, err
+	}
+	if p.match([]TokenType{FUN}) {
+		function, err := p.function("function")
+		function.decorators = decorators
+		return function, err
+	}
+	err = errors.New("Expect function or class after decorator.")
+	p.lx.ParseError(p.peek(), err.Error())
+	return nil, err
+}
+
+// decorators parses any `@decorator` lines. Each decorator is a call
+// expression, so it can be a property or the result of calling a factory.
+func (p *Parser) decorators() ([]Expr, error) {
+	decorators := make([]Expr, 0)
+	for p.match([]TokenType{AT}) {
+		decorator, err := p.call()
+		if err != nil {
+			return nil, err
+		}
+		decorators = append(decorators, decorator)
+	}
+	return decorators, nil
+}
+
+func (p *Parser) class() (Class, error) {
 	name, nameConsumeErr := p.consume(IDENTIFIER, "Expect class name.")
 	if nameConsumeErr != nil {
 		p.lx.ParseError(name, nameConsumeErr.Error())
-		return nil, nameConsumeErr
+		return Class{}, nameConsumeErr
 	}
+	// The id is taken up front so that member accesses in the body can refer to it
+	id := p.getId()
+	enclosingClassId := p.classId
+	p.classId = id
+	defer func() { p.classId = enclosingClassId }()
 	var superclass Variable
 	if p.match([]TokenType{LESS}) {
 		_, superclassConsumeErr := p.consume(IDENTIFIER, "Expect superclass name.")
 		if superclassConsumeErr != nil {
 			p.lx.ParseError(p.peek(), superclassConsumeErr.Error())
-			return nil, superclassConsumeErr
+			return Class{}, superclassConsumeErr
 		}
 		superclass = Variable{name: p.previous(), id: p.getId()}
 	}
+	interfaces := make([]Variable, 0)
+	if p.match([]TokenType{IMPLEMENTS}) {
+		for isComma := true; isComma; isComma = p.match([]TokenType{COMMA}) {
+			_, interfaceConsumeErr := p.consume(IDENTIFIER, "Expect interface name.")
+			if interfaceConsumeErr != nil {
+				p.lx.ParseError(p.peek(), interfaceConsumeErr.Error())
+				return Class{}, interfaceConsumeErr
+			}
+			interfaces = append(interfaces, Variable{name: p.previous(), id: p.getId()})
+		}
+	}
 	_, leftBraceConsumeErr := p.consume(LEFT_BRACE, "Expect '{' before class body.")
+	if leftBraceConsumeErr != nil {
+		p.lx.ParseError(p.peek(), leftBraceConsumeErr.Error())
+		return Class{}, leftBraceConsumeErr
+	}
+	fields := make([]Field, 0)
+	methods := make([]Function, 0)
+	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+		if p.check(IDENTIFIER) && p.checkNext(COLON) || p.check(PRIVATE_IDENTIFIER) && p.checkNext(COLON) {
+			field, fieldErr := p.field()
+			if fieldErr != nil {
+				return Class{}, fieldErr
+			}
+			fields = append(fields, field)
+			continue
+		}
+		decorators, decoratorsErr := p.decorators()
+		if decoratorsErr != nil {
+			return Class{}, decoratorsErr
+		}
+		method, methodErr := p.function("method")
+		if methodErr != nil {
+			return Class{}, methodErr
+		}
+		method.decorators = decorators
+		methods = append(methods, method)
+	}
+	_, rightBraceConsumeErr := p.consume(RIGHT_BRACE, "Expect '}' after class body.")
+	if rightBraceConsumeErr != nil {
+		p.lx.ParseError(p.peek(), rightBraceConsumeErr.Error())
+		return Class{}, rightBraceConsumeErr
+	}
+	return Class{name: name, fields: fields, methods: methods, superclass: superclass, interfaces: interfaces, id: id}, nil
+}
+
+func (p *Parser) field() (Field, error) {
+	name := p.advance()
+	typ, typeErr := p.typeAnnotation()
+	if typeErr != nil {
+		return Field{}, typeErr
+	}
+	_, semicolonConsumeErr := p.consume(SEMICOLON, "Expect ';' after field declaration.")
+	if semicolonConsumeErr != nil {
+		p.lx.ParseError(p.peek(), semicolonConsumeErr.Error())
+		return Field{}, semicolonConsumeErr
+	}
+	return Field{name: name, typ: typ}, nil
+}
+
+// typeAnnotation parses an optional `: type`. Types are named by an identifier,
+// or by nil or class.
+func (p *Parser) typeAnnotation() (TypeAnnotation, error) {
+	if !p.match([]TokenType{COLON}) {
+		return TypeAnnotation{}, nil
+	}
+	if p.match([]TokenType{NIL, CLASS}) {
+		return TypeAnnotation{name: p.previous()}, nil
+	}
+	name, typeConsumeErr := p.consume(IDENTIFIER, "Expect type after ':'.")
+	if typeConsumeErr != nil {
+		p.lx.ParseError(p.peek(), typeConsumeErr.Error())
+		return TypeAnnotation{}, typeConsumeErr
+	}
+	return TypeAnnotation{name: name}, nil
+}
+
+func (p *Parser) interfaceDeclaration() (Stmt, error) {
+	name, nameConsumeErr := p.consume(IDENTIFIER, "Expect interface name.")
+	if nameConsumeErr != nil {
+		p.lx.ParseError(p.peek(), nameConsumeErr.Error())
+		return nil, nameConsumeErr
+	}
+	_, leftBraceConsumeErr := p.consume(LEFT_BRACE, "Expect '{' before interface body.")
 	if leftBraceConsumeErr != nil {
 		p.lx.ParseError(p.peek(), leftBraceConsumeErr.Error())
 		return nil, leftBraceConsumeErr
@@ -97,18 +249,59 @@ func (p *Parser) class() (Stmt, error) {
 		if methodErr != nil {
 			return nil, methodErr
 		}
+		if !method.isAbstract {
+			p.lx.ParseError(method.name, "Interface methods can't have a body.")
+		}
 		methods = append(methods, method)
 	}
-	_, rightBraceConsumeErr := p.consume(RIGHT_BRACE, "Expect '}' after class body.")
+	_, rightBraceConsumeErr := p.consume(RIGHT_BRACE, "Expect '}' after interface body.")
 	if rightBraceConsumeErr != nil {
 		p.lx.ParseError(p.peek(), rightBraceConsumeErr.Error())
 		return nil, rightBraceConsumeErr
 	}
-	return Class{name: name, methods: methods, superclass: superclass, id: p.getId()}, nil
+	return Interface{name: name, methods: methods, id: p.getId()}, nil
+}
+
+func (p *Parser) enum() (Stmt, error) {
+	name, nameConsumeErr := p.consume(IDENTIFIER, "Expect enum name.")
+	if nameConsumeErr != nil {
+		p.lx.ParseError(p.peek(), nameConsumeErr.Error())
+		return nil, nameConsumeErr
+	}
+	_, leftBraceConsumeErr := p.consume(LEFT_BRACE, "Expect '{' before enum body.")
+	if leftBraceConsumeErr != nil {
+		p.lx.ParseError(p.peek(), leftBraceConsumeErr.Error())
+		return nil, leftBraceConsumeErr
+	}
+	members := make([]Token, 0)
+	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+		member, memberConsumeErr := p.consume(IDENTIFIER, "Expect enum member name.")
+		if memberConsumeErr != nil {
+			p.lx.ParseError(p.peek(), memberConsumeErr.Error())
+			return nil, memberConsumeErr
+		}
+		if slices.ContainsFunc(members, func(other Token) bool { return other.lexeme == member.lexeme }) {
+			p.lx.ParseError(member, "Duplicate enum member.")
+		}
+		members = append(members, member)
+		if !p.match([]TokenType{COMMA}) {
+			break
+		}
+	}
+	_, rightBraceConsumeErr := p.consume(RIGHT_BRACE, "Expect '}' after enum body.")
+	if rightBraceConsumeErr != nil {
+		p.lx.ParseError(p.peek(), rightBraceConsumeErr.Error())
+		return nil, rightBraceConsumeErr
+	}
+	return Enum{name: name, members: members, id: p.getId()}, nil
 }
 
 func (p *Parser) function(kind string) (Function, error) {
-	name, identifierConsumeErr := p.consume(IDENTIFIER, fmt.Sprintf("Expect %s name.", kind))
+	nameType := IDENTIFIER
+	if kind == "method" && p.check(PRIVATE_IDENTIFIER) {
+		nameType = PRIVATE_IDENTIFIER
+	}
+	name, identifierConsumeErr := p.consume(nameType, fmt.Sprintf("Expect %s name.", kind))
 	if identifierConsumeErr != nil {
 		p.lx.ParseError(p.peek(), identifierConsumeErr.Error())
 		return Function{}, identifierConsumeErr
@@ -118,17 +311,35 @@ func (p *Parser) function(kind string) (Function, error) {
 		p.lx.ParseError(p.peek(), leftParenConsumeErr.Error())
 		return Function{}, identifierConsumeErr
 	}
-	parameters := make([]Token, 0)
+	parameters := make([]Param, 0)
 	if !p.check(RIGHT_PAREN) {
 		for isComma := true; isComma; isComma = p.match([]TokenType{COMMA}) {
 			if len(parameters) >= 255 {
 				p.lx.ParseError(p.peek(), "Can't have more than 255 parameters.")
 			}
-			param, paramConsumeErr := p.consume(IDENTIFIER, "Expect parameter name.")
+			if len(parameters) > 0 && parameters[len(parameters)-1].variadic {
+				p.lx.ParseError(p.peek(), "Rest parameter must be the last parameter.")
+			}
+			variadic := p.match([]TokenType{ELLIPSIS})
+			name, paramConsumeErr := p.consume(IDENTIFIER, "Expect parameter name.")
 			if paramConsumeErr != nil {
 				p.lx.ParseError(p.peek(), paramConsumeErr.Error())
 				return Function{}, paramConsumeErr
 			}
+			typ, typeErr := p.typeAnnotation()
+			if typeErr != nil {
+				return Function{}, typeErr
+			}
+			param := Param{name: name, typ: typ, variadic: variadic}
+			if !variadic && p.match([]TokenType{EQUAL}) {
+				defaultValue, defaultErr := p.expression()
+				if defaultErr != nil {
+					return Function{}, defaultErr
+				}
+				param.defaultValue = defaultValue
+			} else if !variadic && len(parameters) > 0 && parameters[len(parameters)-1].defaultValue != nil {
+				p.lx.ParseError(name, "Parameter without a default can't follow one with a default.")
+			}
 			parameters = append(parameters, param)
 		}
 	}
@@ -137,24 +348,43 @@ func (p *Parser) function(kind string) (Function, error) {
 		p.lx.ParseError(p.peek(), rightParenConsumeErr.Error())
 		return Function{}, rightParenConsumeErr
 	}
+	returnType, returnTypeErr := p.typeAnnotation()
+	if returnTypeErr != nil {
+		return Function{}, returnTypeErr
+	}
+	if kind == "method" && p.match([]TokenType{SEMICOLON}) {
+		return Function{name: name, params: parameters, returnType: returnType, isAbstract: true, id: p.getId()}, nil
+	}
 	_, leftBraceConsumeErr := p.consume(LEFT_BRACE, fmt.Sprintf("Expect '{' before %s body.", kind))
 	if leftBraceConsumeErr != nil {
 		p.lx.ParseError(p.peek(), leftBraceConsumeErr.Error())
 		return Function{}, leftBraceConsumeErr
 	}
+	// Any yield directly inside the body makes this function a generator
+	enclosingYield := p.sawYield
+	p.sawYield = false
 	body, bodyErr := p.block()
+	isGenerator := p.sawYield
+	p.sawYield = enclosingYield
 	if bodyErr != nil {
 		return Function{}, bodyErr
 	}
-	return Function{name: name, params: parameters, body: body, id: p.getId()}, nil
+	return Function{name: name, params: parameters, returnType: returnType, body: body, isGenerator: isGenerator, id: p.getId()}, nil
 }
 
 func (p *Parser) varDeclaration() (Stmt, error) {
+	if p.match([]TokenType{LEFT_BRACKET, LEFT_BRACE}) {
+		return p.varDestructure()
+	}
 	name, identifierConsumeErr := p.consume(IDENTIFIER, "Expect variable name.")
 	if identifierConsumeErr != nil {
 		p.lx.ParseError(p.peek(), identifierConsumeErr.Error())
 		return nil, errors.New(identifierConsumeErr.Error())
 	}
+	typ, typeErr := p.typeAnnotation()
+	if typeErr != nil {
+		return nil, typeErr
+	}
 	var initializer Expr = nil
 	if p.match([]TokenType{EQUAL}) {
 		var exprErr error
@@ -168,25 +398,111 @@ func (p *Parser) varDeclaration() (Stmt, error) {
 		p.lx.ParseError(p.peek(), semicolonConsumeErr.Error())
 		return nil, errors.New(semicolonConsumeErr.Error())
 	}
-	return Var{name: name, initializer: initializer, id: p.getId()}, nil
+	return Var{name: name, typ: typ, initializer: initializer, id: p.getId()}, nil
+}
+
+func (p *Parser) varDestructure() (Stmt, error) {
+	target, targetErr := p.destructuringTarget()
+	if targetErr != nil {
+		return nil, targetErr
+	}
+	_, equalConsumeErr := p.consume(EQUAL, "Expect '=' after destructuring pattern.")
+	if equalConsumeErr != nil {
+		p.lx.ParseError(p.peek(), equalConsumeErr.Error())
+		return nil, equalConsumeErr
+	}
+	initializer, initializerErr := p.expression()
+	if initializerErr != nil {
+		return nil, initializerErr
+	}
+	_, semicolonConsumeErr := p.consume(SEMICOLON, "Expect ';' after variable declaration.")
+	if semicolonConsumeErr != nil {
+		p.lx.ParseError(p.peek(), semicolonConsumeErr.Error())
+		return nil, semicolonConsumeErr
+	}
+	return VarDestructure{target: target, initializer: initializer, id: p.getId()}, nil
+}
+
+// destructuringTarget parses a list or object pattern whose opening bracket has
+// already been consumed.
+func (p *Parser) destructuringTarget() (DestructuringTarget, error) {
+	open := p.previous()
+	isObject := open.tokenType == LEFT_BRACE
+	closing, closingName := RIGHT_BRACKET, "]"
+	if isObject {
+		closing, closingName = RIGHT_BRACE, "}"
+	}
+	target := DestructuringTarget{open: open, elements: make([]DestructuringElement, 0)}
+	if !p.check(closing) {
+		for isComma := true; isComma; isComma = p.match([]TokenType{COMMA}) {
+			if target.rest.id > 0 {
+				p.lx.ParseError(p.peek(), "Rest element must be the last element.")
+			}
+			if !isObject && p.match([]TokenType{ELLIPSIS}) {
+				name, restConsumeErr := p.consume(IDENTIFIER, "Expect variable name after '...'.")
+				if restConsumeErr != nil {
+					p.lx.ParseError(p.peek(), restConsumeErr.Error())
+					return DestructuringTarget{}, restConsumeErr
+				}
+				target.rest = Variable{name: name, id: p.getId()}
+				continue
+			}
+			name, nameConsumeErr := p.consume(IDENTIFIER, "Expect variable name.")
+			if nameConsumeErr != nil {
+				p.lx.ParseError(p.peek(), nameConsumeErr.Error())
+				return DestructuringTarget{}, nameConsumeErr
+			}
+			element := DestructuringElement{key: name, target: Variable{name: name, id: p.getId()}}
+			if isObject && p.match([]TokenType{COLON}) {
+				binding, bindingConsumeErr := p.consume(IDENTIFIER, "Expect variable name after ':'.")
+				if bindingConsumeErr != nil {
+					p.lx.ParseError(p.peek(), bindingConsumeErr.Error())
+					return DestructuringTarget{}, bindingConsumeErr
+				}
+				element.target = Variable{name: binding, id: p.getId()}
+			}
+			target.elements = append(target.elements, element)
+		}
+	}
+	_, closeConsumeErr := p.consume(closing, fmt.Sprintf("Expect '%s' after destructuring pattern.", closingName))
+	if closeConsumeErr != nil {
+		p.lx.ParseError(p.peek(), closeConsumeErr.Error())
+		return DestructuringTarget{}, closeConsumeErr
+	}
+	return target, nil
 }
 
 func (p *Parser) statement() (Stmt, error) {
+	if p.match([]TokenType{ASSERT}) {
+		return p.assertStatement()
+	}
+	if p.match([]TokenType{DEFER}) {
+		return p.deferStatement()
+	}
 	if p.match([]TokenType{FOR}) {
 		return p.forStatement()
 	}
 	if p.match([]TokenType{IF}) {
 		return p.ifStatement()
 	}
+	if p.match([]TokenType{MATCH}) {
+		return p.matchStatement()
+	}
 	if p.match([]TokenType{PRINT}) {
 		return p.printStatement()
 	}
 	if p.match([]TokenType{RETURN}) {
 		return p.returnStatement()
 	}
+	if p.match([]TokenType{SPAWN}) {
+		return p.spawnStatement()
+	}
 	if p.match([]TokenType{WHILE}) {
 		return p.whileStatement()
 	}
+	if p.match([]TokenType{YIELD}) {
+		return p.yieldStatement()
+	}
 	if p.match([]TokenType{LEFT_BRACE}) {
 		statements, err := p.block()
 		if err != nil {
@@ -197,7 +513,53 @@ func (p *Parser) statement() (Stmt, error) {
 	return p.expressionStatement()
 }
 
+func (p *Parser) assertStatement() (Stmt, error) {
+	keyword := p.previous()
+	start := p.peek()
+	condition, conditionErr := p.expression()
+	if conditionErr != nil {
+		return nil, conditionErr
+	}
+	end := p.previous()
+	source := p.source[start.offset : end.offset+len(end.lexeme)]
+	var message Expr
+	if p.match([]TokenType{COMMA}) {
+		var messageErr error
+		message, messageErr = p.expression()
+		if messageErr != nil {
+			return nil, messageErr
+		}
+	}
+	_, consumeErr := p.consume(SEMICOLON, "Expect ';' after assertion.")
+	if consumeErr != nil {
+		p.lx.ParseError(p.peek(), consumeErr.Error())
+		return nil, consumeErr
+	}
+	return Assert{keyword: keyword, condition: condition, message: message, source: source, id: p.getId()}, nil
+}
+
+func (p *Parser) deferStatement() (Stmt, error) {
+	keyword := p.previous()
+	expr, exprErr := p.expression()
+	if exprErr != nil {
+		return nil, exprErr
+	}
+	call, ok := expr.(Call)
+	if !ok {
+		err := errors.New("Can only defer a call.")
+		p.lx.ParseError(keyword, err.Error())
+		return nil, err
+	}
+	_, semicolonConsumeErr := p.consume(SEMICOLON, "Expect ';' after deferred call.")
+	if semicolonConsumeErr != nil {
+		p.lx.ParseError(p.peek(), semicolonConsumeErr.Error())
+		return nil, semicolonConsumeErr
+	}
+	return Defer{keyword: keyword, call: call, id: p.getId()}, nil
+}
+
 func (p *Parser) forStatement() (Stmt, error) {
+	keyword := p.previous()
 	_, leftParenConsumeErr := p.consume(LEFT_PAREN, "Expect '(' after 'for'.")
 	if leftParenConsumeErr != nil {
 		p.lx.ParseError(p.peek(), leftParenConsumeErr.Error())
@@ -209,6 +571,9 @@ func (p *Parser) forStatement() (Stmt, error) {
 	if p.match([]TokenType{SEMICOLON}) {
 		initializer = nil
 	} else if p.match([]TokenType{VAR}) {
+		if p.check(IDENTIFIER) && p.checkNext(IN) {
+			return p.forInStatement(keyword)
+		}
 		initializer, initializerError = p.varDeclaration()
 		if initializerError != nil {
 			return nil, initializerError
@@ -257,7 +622,7 @@ func (p *Parser) forStatement() (Stmt, error) {
 		body = Block{statments: []Stmt{body, Expression{expr: increment, id: p.getId()}}, id: p.getId()}
 	}
 	if condition == nil {
-		condition = Literal{value: true}
+		condition = Literal{value: boolValue(true)}
 	}
 	body = While{condition: condition, body: body}
 	if initializer != nil {
@@ -266,6 +631,25 @@ func (p *Parser) forStatement() (Stmt, error) {
 	return body, nil
 }
 
+func (p *Parser) forInStatement(keyword Token) (Stmt, error) {
+	name := p.advance()
+	p.advance() // Advance past the 'in'
+	iterable, iterableErr := p.expression()
+	if iterableErr != nil {
+		return nil, iterableErr
+	}
+	_, rightParenConsumeErr := p.consume(RIGHT_PAREN, "Expect ')' after for-in clause.")
+	if rightParenConsumeErr != nil {
+		p.lx.ParseError(p.peek(), rightParenConsumeErr.Error())
+		return nil, rightParenConsumeErr
+	}
+	body, bodyErr := p.statement()
+	if bodyErr != nil {
+		return nil, bodyErr
+	}
+	return ForIn{keyword: keyword, name: name, iterable: iterable, body: body, id: p.getId()}, nil
+}
+
 func (p *Parser) ifStatement() (Stmt, error) {
 	_, leftParenConsumeErr := p.consume(LEFT_PAREN, "Expect '(' after 'if'.")
 	if leftParenConsumeErr != nil {
@@ -296,7 +680,150 @@ func (p *Parser) ifStatement() (Stmt, error) {
 	return If{condition: condition, thenBranch: thenBranch, elseBranch: elseBranch, id: p.getId()}, nil
 }
 
+func (p *Parser) matchStatement() (Stmt, error) {
+	keyword := p.previous()
+	_, leftParenConsumeErr := p.consume(LEFT_PAREN, "Expect '(' after 'match'.")
+	if leftParenConsumeErr != nil {
+		p.lx.ParseError(p.peek(), leftParenConsumeErr.Error())
+		return nil, leftParenConsumeErr
+	}
+	subject, subjectErr := p.expression()
+	if subjectErr != nil {
+		return nil, subjectErr
+	}
+	_, rightParenConsumeErr := p.consume(RIGHT_PAREN, "Expect ')' after match value.")
+	if rightParenConsumeErr != nil {
+		p.lx.ParseError(p.peek(), rightParenConsumeErr.Error())
+		return nil, rightParenConsumeErr
+	}
+	_, leftBraceConsumeErr := p.consume(LEFT_BRACE, "Expect '{' before match cases.")
+	if leftBraceConsumeErr != nil {
+		p.lx.ParseError(p.peek(), leftBraceConsumeErr.Error())
+		return nil, leftBraceConsumeErr
+	}
+	cases := make([]MatchCase, 0)
+	hasDefault := false
+	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+		var patterns []Pattern
+		if p.match([]TokenType{DEFAULT}) {
+			if hasDefault {
+				p.lx.ParseError(p.previous(), "Can't have more than one default case.")
+			}
+			hasDefault = true
+		} else {
+			_, caseConsumeErr := p.consume(CASE, "Expect 'case' or 'default'.")
+			if caseConsumeErr != nil {
+				p.lx.ParseError(p.peek(), caseConsumeErr.Error())
+				return nil, caseConsumeErr
+			}
+			patterns = make([]Pattern, 0)
+			for isComma := true; isComma; isComma = p.match([]TokenType{COMMA}) {
+				pattern, patternErr := p.pattern()
+				if patternErr != nil {
+					return nil, patternErr
+				}
+				patterns = append(patterns, pattern)
+			}
+		}
+		var guard Expr
+		if p.match([]TokenType{IF}) {
+			var guardErr error
+			guard, guardErr = p.expression()
+			if guardErr != nil {
+				return nil, guardErr
+			}
+		}
+		_, arrowConsumeErr := p.consume(ARROW, "Expect '=>' after case pattern.")
+		if arrowConsumeErr != nil {
+			p.lx.ParseError(p.peek(), arrowConsumeErr.Error())
+			return nil, arrowConsumeErr
+		}
+		body, bodyErr := p.statement()
+		if bodyErr != nil {
+			return nil, bodyErr
+		}
+		cases = append(cases, MatchCase{patterns: patterns, guard: guard, body: body})
+	}
+	_, rightBraceConsumeErr := p.consume(RIGHT_BRACE, "Expect '}' after match cases.")
+	if rightBraceConsumeErr != nil {
+		p.lx.ParseError(p.peek(), rightBraceConsumeErr.Error())
+		return nil, rightBraceConsumeErr
+	}
+	return Match{keyword: keyword, subject: subject, cases: cases, id: p.getId()}, nil
+}
+
+func (p *Parser) pattern() (Pattern, error) {
+	if p.match([]TokenType{FALSE}) {
+		return LiteralPattern{value: boolValue(false)}, nil
+	}
+	if p.match([]TokenType{TRUE}) {
+		return LiteralPattern{value: boolValue(true)}, nil
+	}
+	if p.match([]TokenType{NIL}) {
+		return LiteralPattern{value: Value{}}, nil
+	}
+	if p.match([]TokenType{NUMBER, STRING}) {
+		return LiteralPattern{value: valueOf(p.previous().literal)}, nil
+	}
+	if p.match([]TokenType{MINUS}) {
+		number, numberConsumeErr := p.consume(NUMBER, "Expect number after '-' in pattern.")
+		if numberConsumeErr != nil {
+			p.lx.ParseError(p.peek(), numberConsumeErr.Error())
+			return nil, numberConsumeErr
+		}
+		value, _ := negate(valueOf(number.literal))
+		return LiteralPattern{value: value}, nil
+	}
+	name, nameConsumeErr := p.consume(IDENTIFIER, "Expect pattern.")
+	if nameConsumeErr != nil {
+		p.lx.ParseError(p.peek(), nameConsumeErr.Error())
+		return nil, nameConsumeErr
+	}
+	if p.check(DOT) {
+		// A dotted name such as Color.Red is a constant, not a binding
+		var value Expr = Variable{name: name, id: p.getId()}
+		for p.match([]TokenType{DOT}) {
+			property, propertyConsumeErr := p.consume(IDENTIFIER, "Expect property name after '.'.")
+			if propertyConsumeErr != nil {
+				p.lx.ParseError(p.peek(), propertyConsumeErr.Error())
+				return nil, propertyConsumeErr
+			}
+			value = Get{object: value, name: property, id: p.getId()}
+		}
+		return ValuePattern{value: value, token: name}, nil
+	}
+	if !p.match([]TokenType{LEFT_PAREN}) {
+		return BindingPattern{name: name}, nil
+	}
+	fields := make([]FieldPattern, 0)
+	if !p.check(RIGHT_PAREN) {
+		for isComma := true; isComma; isComma = p.match([]TokenType{COMMA}) {
+			field, fieldConsumeErr := p.consume(IDENTIFIER, "Expect field name.")
+			if fieldConsumeErr != nil {
+				p.lx.ParseError(p.peek(), fieldConsumeErr.Error())
+				return nil, fieldConsumeErr
+			}
+			var fieldPattern Pattern = BindingPattern{name: field}
+			if p.match([]TokenType{COLON}) {
+				var fieldPatternErr error
+				fieldPattern, fieldPatternErr = p.pattern()
+				if fieldPatternErr != nil {
+					return nil, fieldPatternErr
+				}
+			}
+			fields = append(fields, FieldPattern{name: field, pattern: fieldPattern})
+		}
+	}
+	_, rightParenConsumeErr := p.consume(RIGHT_PAREN, "Expect ')' after field patterns.")
+	if rightParenConsumeErr != nil {
+		p.lx.ParseError(p.peek(), rightParenConsumeErr.Error())
+		return nil, rightParenConsumeErr
+	}
+	return ClassPattern{class: Variable{name: name, id: p.getId()}, fields: fields}, nil
+}
+
 func (p *Parser) printStatement() (Stmt, error) {
+	keyword := p.previous()
 	value, exprErr := p.expression()
 	if exprErr != nil {
 		return nil, exprErr
@@ -306,7 +833,7 @@ func (p *Parser) printStatement() (Stmt, error) {
 		p.lx.ParseError(p.peek(), consumeErr.Error())
 		return nil, errors.New(consumeErr.Error())
 	}
-	return Print{expr: value, id: p.getId()}, nil
+	return Print{keyword: keyword, expr: value, id: p.getId()}, nil
 }
 
 func (p *Parser) returnStatement() (Stmt, error) {
@@ -318,6 +845,18 @@ func (p *Parser) returnStatement() (Stmt, error) {
 		if valueErr != nil {
 			return nil, valueErr
 		}
+		// Several return values are packed into a list
+		if p.check(COMMA) {
+			values := []Expr{value}
+			for p.match([]TokenType{COMMA}) {
+				next, nextErr := p.expression()
+				if nextErr != nil {
+					return nil, nextErr
+				}
+				values = append(values, next)
+			}
+			value = List{bracket: keyword, elements: values, id: p.getId()}
+		}
 	}
 	_, semicolonConsumeErr := p.consume(SEMICOLON, "Expect ';' after return value.")
 	if semicolonConsumeErr != nil {
@@ -327,6 +866,25 @@ func (p *Parser) returnStatement() (Stmt, error) {
 	return Return{keyword: keyword, value: value, id: p.getId()}, nil
 }
 
+func (p *Parser) spawnStatement() (Stmt, error) {
+	keyword := p.previous()
+	expr, exprErr := p.expression()
+	if exprErr != nil {
+		return nil, exprErr
+	}
+	call, ok := expr.(Call)
+	if !ok {
+		p.lx.ParseError(keyword, "Expect function call after 'spawn'.")
+		return nil, errors.New("Expect function call after 'spawn'.")
+	}
+	_, semicolonConsumeErr := p.consume(SEMICOLON, "Expect ';' after spawned call.")
+	if semicolonConsumeErr != nil {
+		p.lx.ParseError(p.peek(), semicolonConsumeErr.Error())
+		return nil, semicolonConsumeErr
+	}
+	return Spawn{keyword: keyword, call: call, id: p.getId()}, nil
+}
+
 func (p *Parser) whileStatement() (Stmt, error) {
 	_, leftParenConsumeErr := p.consume(LEFT_PAREN, "Expect '(' after 'while'.")
 	if leftParenConsumeErr != nil {
@@ -349,6 +907,25 @@ func (p *Parser) whileStatement() (Stmt, error) {
 	return While{condition: condition, body: body, id: p.getId()}, nil
 }
 
+func (p *Parser) yieldStatement() (Stmt, error) {
+	keyword := p.previous()
+	p.sawYield = true
+	var value Expr
+	var valueErr error
+	if !p.check(SEMICOLON) {
+		value, valueErr = p.expression()
+		if valueErr != nil {
+			return nil, valueErr
+		}
+	}
+	_, semicolonConsumeErr := p.consume(SEMICOLON, "Expect ';' after yield value.")
+	if semicolonConsumeErr != nil {
+		p.lx.ParseError(p.peek(), semicolonConsumeErr.Error())
+		return nil, semicolonConsumeErr
+	}
+	return Yield{keyword: keyword, value: value, id: p.getId()}, nil
+}
+
 func (p *Parser) block() ([]Stmt, error) {
 	statements := make([]Stmt, 0)
 	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
@@ -386,7 +963,7 @@ func (p *Parser) expression() (Expr, error) {
 }
 
 func (p *Parser) assignment() (Expr, error) {
-	expr, err := p.or()
+	expr, err := p.pipe()
 	if err != nil {
 		return nil, err
 	}
@@ -401,7 +978,22 @@ func (p *Parser) assignment() (Expr, error) {
 			name := t.name
 			return Assign{name: name, value: value, id: p.getId()}, nil
 		case Get:
-			return Set{object: t.object, name: t.name, value: value, id: p.getId()}, nil
+			if t.optional {
+				p.lx.ParseError(equals, "Invalid assignment target.")
+				return expr, nil
+			}
+			return Set{object: t.object, name: t.name, value: value, classId: t.classId, id: p.getId()}, nil
+		case List:
+			target := DestructuringTarget{open: t.bracket, elements: make([]DestructuringElement, 0)}
+			for _, element := range t.elements {
+				variable, ok := element.(Variable)
+				if !ok {
+					p.lx.ParseError(equals, "Invalid assignment target.")
+					return expr, nil
+				}
+				target.elements = append(target.elements, DestructuringElement{key: variable.name, target: variable})
+			}
+			return AssignDestructure{target: target, value: value, id: p.getId()}, nil
 		default:
 			p.lx.ParseError(equals, "Invalid assignment target.")
 		}
@@ -409,6 +1001,44 @@ func (p *Parser) assignment() (Expr, error) {
 	return expr, nil
 }
 
+// pipe parses `x |> f(a)` as the call `f(x, a)`, and `x |> f` as `f(x)`.
+func (p *Parser) pipe() (Expr, error) {
+	expr, err := p.coalesce()
+	if err != nil {
+		return nil, err
+	}
+	for p.match([]TokenType{PIPE}) {
+		operator := p.previous()
+		right, err := p.coalesce()
+		if err != nil {
+			return nil, err
+		}
+		call, ok := right.(Call)
+		if !ok {
+			call = Call{callee: right, paren: operator, id: p.getId()}
+		}
+		call.arguments = append([]Expr{expr}, call.arguments...)
+		expr = call
+	}
+	return expr, nil
+}
+
+func (p *Parser) coalesce() (Expr, error) {
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	for p.match([]TokenType{QUESTION_QUESTION}) {
+		operator := p.previous()
+		right, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		expr = Logical{left: expr, operator: operator, right: right, id: p.getId()}
+	}
+	return expr, nil
+}
+
 func (p *Parser) or() (Expr, error) {
 	expr, err := p.and()
 	if err != nil {
@@ -458,13 +1088,13 @@ func (p *Parser) equality() (Expr, error) {
 }
 
 func (p *Parser) comparison() (Expr, error) {
-	expr, err := p.term()
+	expr, err := p.rangeExpression()
 	if err != nil {
 		return expr, err
 	}
-	for p.match([]TokenType{GREATER_EQUAL, GREATER, LESS, LESS_EQUAL}) {
+	for p.match([]TokenType{GREATER_EQUAL, GREATER, LESS, LESS_EQUAL, IS}) {
 		operator := p.previous()
-		right, err := p.term()
+		right, err := p.rangeExpression()
 		if err != nil {
 			return right, err
 		}
@@ -473,6 +1103,23 @@ func (p *Parser) comparison() (Expr, error) {
 	return expr, nil
 }
 
+// Ranges don't chain, so `a..b..c` is a syntax error.
+func (p *Parser) rangeExpression() (Expr, error) {
+	expr, err := p.term()
+	if err != nil {
+		return expr, err
+	}
+	if p.match([]TokenType{DOT_DOT, DOT_DOT_LESS}) {
+		operator := p.previous()
+		end, err := p.term()
+		if err != nil {
+			return end, err
+		}
+		expr = Range{start: expr, operator: operator, end: end, id: p.getId()}
+	}
+	return expr, nil
+}
+
 func (p *Parser) term() (Expr, error) {
 	expr, err := p.factor()
 	if err != nil {
@@ -522,6 +1169,7 @@ func (p *Parser) call() (Expr, error) {
 	if err != nil {
 		return nil, err
 	}
+	isOptional := false
 	for {
 		if p.match([]TokenType{LEFT_PAREN}) {
 			var finishCallErr error
@@ -529,28 +1177,99 @@ func (p *Parser) call() (Expr, error) {
 			if finishCallErr != nil {
 				return nil, finishCallErr
 			}
-		} else if p.match([]TokenType{DOT}) {
-			name, nameConsumeErr := p.consume(IDENTIFIER, "Expect property name after '.'.")
+		} else if p.match([]TokenType{DOT, QUESTION_DOT}) {
+			optional := p.previous().tokenType == QUESTION_DOT
+			nameType := IDENTIFIER
+			if p.check(PRIVATE_IDENTIFIER) {
+				nameType = PRIVATE_IDENTIFIER
+			}
+			name, nameConsumeErr := p.consume(nameType, "Expect property name after '.'.")
 			if nameConsumeErr != nil {
 				p.lx.ParseError(p.peek(), nameConsumeErr.Error())
 				return nil, nameConsumeErr
 			}
-			expr = Get{object: expr, name: name, id: p.getId()}
+			expr = Get{object: expr, name: name, optional: optional, classId: p.classId, id: p.getId()}
+			isOptional = isOptional || optional
+		} else if p.match([]TokenType{LEFT_BRACKET}) {
+			bracket := p.previous()
+			index, indexErr := p.expression()
+			if indexErr != nil {
+				return nil, indexErr
+			}
+			_, rightBracketConsumeErr := p.consume(RIGHT_BRACKET, "Expect ']' after index.")
+			if rightBracketConsumeErr != nil {
+				p.lx.ParseError(p.peek(), rightBracketConsumeErr.Error())
+				return nil, rightBracketConsumeErr
+			}
+			expr = Index{object: expr, bracket: bracket, index: index, id: p.getId()}
 		} else {
 			break
 		}
 	}
+	if isOptional {
+		expr = OptionalChain{expression: expr, id: p.getId()}
+	}
 	return expr, nil
 }
 
+func (p *Parser) comprehension(bracket Token, element Expr) (Expr, error) {
+	name, nameConsumeErr := p.consume(IDENTIFIER, "Expect variable name after 'for'.")
+	if nameConsumeErr != nil {
+		p.lx.ParseError(p.peek(), nameConsumeErr.Error())
+		return nil, nameConsumeErr
+	}
+	_, inConsumeErr := p.consume(IN, "Expect 'in' after comprehension variable.")
+	if inConsumeErr != nil {
+		p.lx.ParseError(p.peek(), inConsumeErr.Error())
+		return nil, inConsumeErr
+	}
+	iterable, iterableErr := p.expression()
+	if iterableErr != nil {
+		return nil, iterableErr
+	}
+	var condition Expr
+	if p.match([]TokenType{IF}) {
+		var conditionErr error
+		condition, conditionErr = p.expression()
+		if conditionErr != nil {
+			return nil, conditionErr
+		}
+	}
+	_, rightBracketConsumeErr := p.consume(RIGHT_BRACKET, "Expect ']' after comprehension.")
+	if rightBracketConsumeErr != nil {
+		p.lx.ParseError(p.peek(), rightBracketConsumeErr.Error())
+		return nil, rightBracketConsumeErr
+	}
+	return Comprehension{bracket: bracket, element: element, name: name, iterable: iterable, condition: condition, id: p.getId()}, nil
+}
+
 func (p *Parser) finishCall(callee Expr) (Expr, error) {
 	arguments := make([]Expr, 0)
+	namedArguments := make([]NamedArgument, 0)
 	if !p.check(RIGHT_PAREN) {
 		for next := true; next; next = p.match([]TokenType{COMMA}) {
-			if len(arguments) >= 255 {
+			if len(arguments)+len(namedArguments) >= 255 {
 				p.lx.ParseError(p.peek(), "Can't have more than 255 arguments.")
 			}
-			arg, err := p.expression()
+			if p.check(IDENTIFIER) && p.checkNext(COLON) {
+				name := p.advance()
+				p.advance() // Advance past the ':'
+				for _, named := range namedArguments {
+					if named.name.lexeme == name.lexeme {
+						p.lx.ParseError(name, "Duplicate named argument.")
+					}
+				}
+				value, err := p.expression()
+				if err != nil {
+					return nil, err
+				}
+				namedArguments = append(namedArguments, NamedArgument{name: name, value: value})
+				continue
+			}
+			if len(namedArguments) > 0 {
+				p.lx.ParseError(p.peek(), "Positional argument can't follow a named argument.")
+			}
+			arg, err := p.spreadable()
 			if err != nil {
 				return nil, err
 			}
@@ -562,25 +1281,65 @@ func (p *Parser) finishCall(callee Expr) (Expr, error) {
 		p.lx.ParseError(p.peek(), consumeErr.Error())
 		return nil, consumeErr
 	}
-	return Call{callee: callee, paren: paren, arguments: arguments, id: p.getId()}, nil
+	return Call{callee: callee, paren: paren, arguments: arguments, namedArguments: namedArguments, id: p.getId()}, nil
+}
+
+// spreadable parses an argument or list element, which may be spread.
+func (p *Parser) spreadable() (Expr, error) {
+	if p.match([]TokenType{ELLIPSIS}) {
+		ellipsis := p.previous()
+		expr, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		return Spread{ellipsis: ellipsis, expression: expr, id: p.getId()}, nil
+	}
+	return p.expression()
 }
 
 func (p *Parser) primary() (Expr, error) {
 	if p.match([]TokenType{FALSE}) {
-		return Literal{value: false, id: p.getId()}, nil
+		return Literal{value: boolValue(false), id: p.getId()}, nil
 	}
 	if p.match([]TokenType{TRUE}) {
-		return Literal{value: true, id: p.getId()}, nil
+		return Literal{value: boolValue(true), id: p.getId()}, nil
 	}
 	if p.match([]TokenType{NIL}) {
-		return Literal{value: nil, id: p.getId()}, nil
+		return Literal{value: Value{}, id: p.getId()}, nil
 	}
 	if p.match([]TokenType{NUMBER, STRING}) {
-		return Literal{value: p.previous().literal, id: p.getId()}, nil
+		return Literal{value: valueOf(p.previous().literal), id: p.getId()}, nil
 	}
 	if p.match([]TokenType{IDENTIFIER}) {
 		return Variable{name: p.previous(), id: p.getId()}, nil
 	}
+	if p.match([]TokenType{LEFT_BRACKET}) {
+		bracket := p.previous()
+		elements := make([]Expr, 0)
+		if !p.check(RIGHT_BRACKET) {
+			element, elementErr := p.spreadable()
+			if elementErr != nil {
+				return nil, elementErr
+			}
+			if _, isSpread := element.(Spread); !isSpread && p.match([]TokenType{FOR}) {
+				return p.comprehension(bracket, element)
+			}
+			elements = append(elements, element)
+			for p.match([]TokenType{COMMA}) {
+				element, elementErr := p.spreadable()
+				if elementErr != nil {
+					return nil, elementErr
+				}
+				elements = append(elements, element)
+			}
+		}
+		_, rightBracketConsumeErr := p.consume(RIGHT_BRACKET, "Expect ']' after list elements.")
+		if rightBracketConsumeErr != nil {
+			p.lx.ParseError(p.peek(), rightBracketConsumeErr.Error())
+			return nil, rightBracketConsumeErr
+		}
+		return List{bracket: bracket, elements: elements, id: p.getId()}, nil
+	}
 	if p.match([]TokenType{SUPER}) {
 		keyword := p.previous()
 		_, dotConsumeErr := p.consume(DOT, "Expect '.' after 'super'.")
@@ -638,6 +1397,13 @@ func (p *Parser) check(tokenType TokenType) bool {
 	return p.peek().tokenType == tokenType
 }
 
+func (p *Parser) checkNext(tokenType TokenType) bool {
+	if p.isAtEnd() || p.tokens[p.current+1].tokenType == EOF {
+		return false
+	}
+	return p.tokens[p.current+1].tokenType == tokenType
+}
+
 func (p *Parser) advance() Token {
 	if !p.isAtEnd() {
 		p.current += 1
@@ -664,14 +1430,7 @@ func (p *Parser) synchronize() {
 			return
 		}
 		switch p.peek().tokenType {
-		case CLASS:
-		case FOR:
-		case FUN:
-		case IF:
-		case PRINT:
-		case RETURN:
-		case VAR:
-		case WHILE:
+		case CLASS, ENUM, FOR, FUN, IF, INTERFACE, PRINT, RETURN, SPAWN, VAR, WHILE, YIELD:
 			return
 		}
 		p.advance()
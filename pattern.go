@@ -0,0 +1,53 @@
+package main
+
+// Patterns only appear in the cases of a match statement, so they are matched
+// with a type switch instead of getting a visitor of their own.
+type Pattern interface {
+	bindings() []Token
+}
+
+type LiteralPattern struct {
+	value Value
+}
+
+func (lp LiteralPattern) bindings() []Token { return nil }
+
+// A ValuePattern matches values equal to a dotted constant like Color.Red.
+type ValuePattern struct {
+	value Expr
+	token Token
+}
+
+func (vp ValuePattern) bindings() []Token { return nil }
+
+// A BindingPattern matches anything and binds it to name. The name "_" is a
+// wildcard and binds nothing.
+type BindingPattern struct {
+	name Token
+}
+
+func (bp BindingPattern) bindings() []Token {
+	if bp.name.lexeme == "_" {
+		return nil
+	}
+	return []Token{bp.name}
+}
+
+type ClassPattern struct {
+	class  Variable
+	fields []FieldPattern
+}
+
+func (cp ClassPattern) bindings() []Token {
+	output := make([]Token, 0)
+	for _, field := range cp.fields {
+		output = append(output, field.pattern.bindings()...)
+	}
+	return output
+}
+
+// A FieldPattern written as just `x` is shorthand for `x: x`.
+type FieldPattern struct {
+	name    Token
+	pattern Pattern
+}
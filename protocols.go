@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+// Instances can take part in printing, equality and hashing by defining
+// toString, equals and hash methods. An __eq__ method is used as equals. Without
+// them, instances, classes and functions are compared by identity.
+
+// callMethod calls method bound to instance. Errors are reported at token.
+func (interp *Interpreter) callMethod(instance LoxInstance, method LoxFunction, arguments []Value, token Token) (Value, error) {
+	bound := method.bind(instance)
+	if arityErr := checkArity(bound, len(arguments)); arityErr != nil {
+		interp.lx.RuntimeError(token, arityErr)
+		interp.err = arityErr
+		interp.badToken = token
+		return Value{}, arityErr
+	}
+	interp.callSite = token
+	output := bound.call(interp, arguments)
+	if interp.err != nil {
+		return Value{}, interp.err
+	}
+	return output, nil
+}
+
+// protocolMethod finds the method called name if value is an instance that
+// defines it.
+func protocolMethod(value Value, name string) (LoxInstance, LoxFunction, bool) {
+	instance, ok := value.ref.(LoxInstance)
+	if !ok {
+		return LoxInstance{}, LoxFunction{}, false
+	}
+	method, err := instance.klass.findMethod(name)
+	if err != nil {
+		return LoxInstance{}, LoxFunction{}, false
+	}
+	return instance, method, true
+}
+
+// display converts val to the text print shows for it.
+func (interp *Interpreter) display(val Value, token Token) (string, error) {
+	switch v := val.ref.(type) {
+	case LoxInstance:
+		if _, method, ok := protocolMethod(val, "toString"); ok {
+			output, err := interp.callMethod(v, method, []Value{}, token)
+			if err != nil {
+				return "", err
+			}
+			if !output.isString() {
+				err := fmt.Errorf("toString must return a string.")
+				interp.lx.RuntimeError(token, err)
+				interp.err = err
+				interp.badToken = token
+				return "", err
+			}
+			return output.asString(), nil
+		}
+	case *LoxList:
+		elements := make([]string, len(v.elements))
+		for i, element := range v.elements {
+			text, err := interp.display(element, token)
+			if err != nil {
+				return "", err
+			}
+			elements[i] = text
+		}
+		return "[" + strings.Join(elements, ", ") + "]", nil
+	}
+	return val.String(), nil
+}
+
+// equalsMethod finds the equals method of value, which may be called __eq__.
+func equalsMethod(value Value) (LoxInstance, LoxFunction, bool) {
+	if instance, method, ok := protocolMethod(value, "equals"); ok {
+		return instance, method, true
+	}
+	return protocolMethod(value, "__eq__")
+}
+
+// equals compares two values, using an equals method on either operand.
+func (interp *Interpreter) equals(left Value, right Value, token Token) (bool, error) {
+	instance, method, ok := equalsMethod(left)
+	if !ok {
+		instance, method, ok = equalsMethod(right)
+		left, right = right, left
+	}
+	if !ok {
+		return isEqual(left, right), nil
+	}
+	output, err := interp.callMethod(instance, method, []Value{right}, token)
+	if err != nil {
+		return false, err
+	}
+	return isTruthy(output), nil
+}
+
+// hash returns a hash of val that is the same for any two values that are
+// equal, using a hash method on instances that define one. Instances that
+// define equals without hash can't be hashed, since hashing them by identity
+// would give equal instances different hashes.
+func (interp *Interpreter) hash(val Value, token Token) (int64, error) {
+	if instance, method, ok := protocolMethod(val, "hash"); ok {
+		output, err := interp.callMethod(instance, method, []Value{}, token)
+		if err != nil {
+			return 0, err
+		}
+		if output.kind != INT_KIND {
+			err := fmt.Errorf("hash must return an integer.")
+			interp.lx.RuntimeError(token, err)
+			interp.err = err
+			interp.badToken = token
+			return 0, err
+		}
+		return output.asInt(), nil
+	}
+	if instance, _, ok := equalsMethod(val); ok {
+		err := fmt.Errorf("Can't hash '%s' instances, since the class defines equals but not hash.", instance.klass.name)
+		interp.lx.RuntimeError(token, err)
+		interp.err = err
+		interp.badToken = token
+		return 0, err
+	}
+	return hashValue(val), nil
+}
+
+func hashValue(val Value) int64 {
+	switch val.kind {
+	case NIL_KIND:
+		return 0
+	case BOOL_KIND:
+		if val.asBool() {
+			return 1
+		}
+		return 0
+	case INT_KIND:
+		return val.asInt()
+	case FLOAT_KIND:
+		v := val.asFloat()
+		// Integral floats must hash like the integers they equal
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			if v >= math.MinInt64 && v < math.MaxInt64 {
+				return int64(v)
+			}
+			integer, _ := big.NewFloat(v).Int(nil)
+			return hashString(integer.String())
+		}
+		return int64(math.Float64bits(v))
+	case BIG_INT_KIND:
+		return hashString(val.asBigInt().String())
+	case STRING_KIND:
+		return hashString(val.asString())
+	}
+	switch v := val.ref.(type) {
+	case LoxInstance:
+		return int64(reflect.ValueOf(v.fields).Pointer())
+	case LoxClass:
+		return int64(reflect.ValueOf(v.methods).Pointer())
+	case LoxFunction:
+		return v.hash()
+	case unboundMethod:
+		return v.method.hash()
+	}
+	if reflect.TypeOf(val.ref).Kind() == reflect.Pointer {
+		return int64(reflect.ValueOf(val.ref).Pointer())
+	}
+	return hashString(val.String())
+}
+
+func hashString(text string) int64 {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(text))
+	return int64(hasher.Sum64())
+}
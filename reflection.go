@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Reflection natives let scripts inspect values. Private members are left out
+// of the names they list, since they can't be accessed from outside anyway.
+
+var typeofFunction = &NativeFunction{name: "typeof", minArity: 1, maxArity: 1, function: func(interp *Interpreter, args []Value) (Value, error) {
+	return stringValue(typeName(args[0])), nil
+}}
+
+// typeName uses the same names as type annotations where there is one.
+func typeName(val Value) string {
+	switch val.kind {
+	case NIL_KIND:
+		return "nil"
+	case BOOL_KIND:
+		return "bool"
+	case INT_KIND, FLOAT_KIND, BIG_INT_KIND:
+		return "number"
+	case STRING_KIND:
+		return "string"
+	}
+	switch val.ref.(type) {
+	case LoxInstance:
+		return "instance"
+	case LoxClass, *NativeClass:
+		return "class"
+	case *LoxInterface:
+		return "interface"
+	case *LoxEnum:
+		return "enum"
+	case *LoxEnumMember:
+		return "enum member"
+	case *LoxList:
+		return "list"
+	case *LoxRange:
+		return "range"
+	case *LoxGenerator:
+		return "generator"
+	case *LoxChannel:
+		return "channel"
+	case *LoxWaitGroup:
+		return "waitgroup"
+	case LoxCallable:
+		return "function"
+	}
+	return "object"
+}
+
+var fieldsFunction = &NativeFunction{name: "fields", minArity: 1, maxArity: 1, function: func(interp *Interpreter, args []Value) (Value, error) {
+	instance, ok := args[0].ref.(LoxInstance)
+	if !ok {
+		return Value{}, errors.New("Only instances have fields.")
+	}
+	names := slices.DeleteFunc(instance.fieldNames(), func(name string) bool {
+		return strings.HasPrefix(name, "#")
+	})
+	slices.Sort(names)
+	return objectValue(stringList(names)), nil
+}}
+
+// methods lists the methods of a class, or of an instance's class, including
+// the ones it inherits.
+var methodsFunction = &NativeFunction{name: "methods", minArity: 1, maxArity: 1, function: func(interp *Interpreter, args []Value) (Value, error) {
+	klass, err := classArgument(args[0])
+	if err != nil {
+		return Value{}, err
+	}
+	names := make([]string, 0)
+	for c := &klass; c != nil; c = c.superclass {
+		for name := range c.methods {
+			if !strings.HasPrefix(name, "#") && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return objectValue(stringList(names)), nil
+}}
+
+// arity doesn't count parameters with defaults or rest parameters, since the
+// callable can be called without them.
+var arityFunction = &NativeFunction{name: "arity", minArity: 1, maxArity: 1, function: func(interp *Interpreter, args []Value) (Value, error) {
+	callable, ok := args[0].ref.(LoxCallable)
+	if !ok {
+		return Value{}, errors.New("Can only get the arity of functions and classes.")
+	}
+	min, _ := callable.arity()
+	return intValue(int64(min)), nil
+}}
+
+var nameFunction = &NativeFunction{name: "name", minArity: 1, maxArity: 1, function: func(interp *Interpreter, args []Value) (Value, error) {
+	switch v := args[0].ref.(type) {
+	case LoxFunction:
+		return stringValue(v.declaration.name.lexeme), nil
+	case unboundMethod:
+		return stringValue(v.method.declaration.name.lexeme), nil
+	case *NativeFunction:
+		return stringValue(v.name), nil
+	case LoxClass:
+		return stringValue(v.name), nil
+	case *NativeClass:
+		return stringValue(v.name), nil
+	case *LoxInterface:
+		return stringValue(v.name), nil
+	case *LoxEnum:
+		return stringValue(v.name), nil
+	case *LoxEnumMember:
+		return stringValue(v.name), nil
+	}
+	return Value{}, errors.New("Only functions, classes, interfaces and enums have names.")
+}}
+
+var classOfFunction = &NativeFunction{name: "classOf", minArity: 1, maxArity: 1, function: func(interp *Interpreter, args []Value) (Value, error) {
+	instance, ok := args[0].ref.(LoxInstance)
+	if !ok {
+		return Value{}, errors.New("Only instances have a class.")
+	}
+	return objectValue(instance.klass), nil
+}}
+
+var classNameFunction = &NativeFunction{name: "className", minArity: 1, maxArity: 1, function: func(interp *Interpreter, args []Value) (Value, error) {
+	instance, ok := args[0].ref.(LoxInstance)
+	if !ok {
+		return Value{}, errors.New("Only instances have a class.")
+	}
+	return stringValue(instance.klass.name), nil
+}}
+
+// superclass returns nil for classes without one, so the chain can be walked
+// with a loop.
+var superclassFunction = &NativeFunction{name: "superclass", minArity: 1, maxArity: 1, function: func(interp *Interpreter, args []Value) (Value, error) {
+	klass, err := classArgument(args[0])
+	if err != nil {
+		return Value{}, err
+	}
+	if klass.superclass == nil {
+		return Value{}, nil
+	}
+	return objectValue(*klass.superclass), nil
+}}
+
+var instanceofFunction = &NativeFunction{name: "instanceof", minArity: 2, maxArity: 2, function: func(interp *Interpreter, args []Value) (Value, error) {
+	result, err := isInstance(args[0], args[1])
+	if err != nil {
+		return Value{}, errors.New("Second argument must be a class or interface.")
+	}
+	return boolValue(result), nil
+}}
+
+// classArgument accepts a class or an instance standing in for its class.
+func classArgument(val Value) (LoxClass, error) {
+	switch v := val.ref.(type) {
+	case LoxClass:
+		return v, nil
+	case LoxInstance:
+		return v.klass, nil
+	}
+	return LoxClass{}, errors.New("Argument must be a class or an instance.")
+}
@@ -1,8 +1,14 @@
 package main
 
+import (
+	"fmt"
+	"slices"
+)
+
 type Resolver struct {
 	interp          *Interpreter
 	scopes          []map[string]bool
+	enums           []map[string]Enum
 	currentFunction FunctionType
 	currentClass    ClassType
 	lx              *Lox
@@ -15,6 +21,7 @@ const (
 	FUNCTION
 	INITIALIZER
 	METHOD
+	GENERATOR
 )
 
 type ClassType int
@@ -25,6 +32,13 @@ const (
 	SUBCLASS
 )
 
+func (r *Resolver) visitAssert(stmt Assert) {
+	r.resolveExpression(stmt.condition)
+	if stmt.message != nil {
+		r.resolveExpression(stmt.message)
+	}
+}
+
 func (r *Resolver) visitBlock(stmt Block) {
 	r.beginScope()
 	r.resolveStatements(stmt.statments)
@@ -42,6 +56,11 @@ func (r *Resolver) resolveStatement(stmt Stmt) {
 }
 
 func (r *Resolver) visitClass(stmt Class) {
+	// Decorators are evaluated before the class exists, in the enclosing scope
+	r.resolveExpressions(stmt.decorators)
+	for _, method := range stmt.methods {
+		r.resolveExpressions(method.decorators)
+	}
 	enclosingClass := r.currentClass
 	r.currentClass = YESCLASS
 	r.declare(stmt.name)
@@ -53,6 +72,9 @@ func (r *Resolver) visitClass(stmt Class) {
 		r.currentClass = SUBCLASS
 		r.resolveExpression(stmt.superclass)
 	}
+	for _, iface := range stmt.interfaces {
+		r.resolveExpression(iface)
+	}
 	if stmt.superclass.id > 0 {
 		r.beginScope()
 		r.scopes[len(r.scopes)-1]["super"] = true
@@ -60,6 +82,12 @@ func (r *Resolver) visitClass(stmt Class) {
 	r.beginScope()
 	r.scopes[len(r.scopes)-1]["this"] = true
 	for _, method := range stmt.methods {
+		if method.isAbstract {
+			if method.name.lexeme == "init" {
+				r.lx.ResolveError(method.name, "An initializer can't be abstract.")
+			}
+			continue
+		}
 		declaration := METHOD
 		if method.name.lexeme == "init" {
 			declaration = INITIALIZER
@@ -73,11 +101,48 @@ func (r *Resolver) visitClass(stmt Class) {
 	r.currentClass = enclosingClass
 }
 
+func (r *Resolver) visitDefer(stmt Defer) {
+	switch r.currentFunction {
+	case NONE:
+		r.lx.ResolveError(stmt.keyword, "Can't defer outside of a function.")
+	case GENERATOR:
+		// A generator that is never exhausted has no exit to run them at
+		r.lx.ResolveError(stmt.keyword, "Can't defer in a generator.")
+	}
+	r.resolveExpression(stmt.call)
+}
+
+func (r *Resolver) visitEnum(stmt Enum) {
+	r.declare(stmt.name)
+	r.define(stmt.name)
+	r.enums[len(r.scopes)][stmt.name.lexeme] = stmt
+}
+
+func (r *Resolver) visitInterface(stmt Interface) {
+	r.declare(stmt.name)
+	r.define(stmt.name)
+	for _, method := range stmt.methods {
+		if method.name.lexeme == "init" {
+			r.lx.ResolveError(method.name, "An initializer can't be abstract.")
+		}
+	}
+}
+
 func (r *Resolver) visitExpression(stmt Expression) {
 	r.resolveExpression(stmt.expr)
 }
 
+func (r *Resolver) visitForIn(stmt ForIn) {
+	r.resolveExpression(stmt.iterable)
+	r.beginScope()
+	r.declare(stmt.name)
+	r.define(stmt.name)
+	r.resolveStatement(stmt.body)
+	r.endScope()
+}
+
 func (r *Resolver) visitFunction(stmt Function) {
+	r.resolveExpressions(stmt.decorators)
 	r.declare(stmt.name)
 	r.define(stmt.name)
 	r.resolveFunction(stmt, FUNCTION)
@@ -91,6 +156,41 @@ func (r *Resolver) visitIf(stmt If) {
 	}
 }
 
+func (r *Resolver) visitMatch(stmt Match) {
+	r.resolveExpression(stmt.subject)
+	for _, matchCase := range stmt.cases {
+		r.beginScope()
+		for _, pattern := range matchCase.patterns {
+			if len(matchCase.patterns) > 1 && len(pattern.bindings()) > 0 {
+				r.lx.ResolveError(pattern.bindings()[0], "Can't bind variables in a case with several patterns.")
+			}
+			r.resolvePattern(pattern)
+		}
+		if matchCase.guard != nil {
+			r.resolveExpression(matchCase.guard)
+		}
+		r.resolveStatement(matchCase.body)
+		r.endScope()
+	}
+}
+
+func (r *Resolver) resolvePattern(pattern Pattern) {
+	switch p := pattern.(type) {
+	case BindingPattern:
+		if p.name.lexeme != "_" {
+			r.declare(p.name)
+			r.define(p.name)
+		}
+	case ValuePattern:
+		r.resolveExpression(p.value)
+	case ClassPattern:
+		r.resolveLocal(p.class.id, p.class.name)
+		for _, field := range p.fields {
+			r.resolvePattern(field.pattern)
+		}
+	}
+}
+
 func (r *Resolver) visitPrint(stmt Print) {
 	r.resolveExpression(stmt.expr)
 }
@@ -103,6 +203,10 @@ func (r *Resolver) visitReturn(stmt Return) {
 		if stmt.value != nil {
 			r.lx.ResolveError(stmt.keyword, "Can't return a value from an initializer.")
 		}
+	case GENERATOR:
+		if stmt.value != nil {
+			r.lx.ResolveError(stmt.keyword, "Can't return a value from a generator.")
+		}
 	default:
 		if stmt.value == nil {
 			return
@@ -111,18 +215,40 @@ func (r *Resolver) visitReturn(stmt Return) {
 	}
 }
 
+func (r *Resolver) visitSpawn(stmt Spawn) {
+	r.resolveExpression(stmt.call)
+}
+
 func (r *Resolver) visitWhile(stmt While) {
 	r.resolveExpression(stmt.condition)
 	r.resolveStatement(stmt.body)
 }
 
+func (r *Resolver) visitYield(stmt Yield) {
+	switch r.currentFunction {
+	case NONE:
+		r.lx.ResolveError(stmt.keyword, "Can't yield from top-level code.")
+	case INITIALIZER:
+		r.lx.ResolveError(stmt.keyword, "Can't yield from an initializer.")
+	}
+	if stmt.value != nil {
+		r.resolveExpression(stmt.value)
+	}
+}
+
 func (r *Resolver) resolveFunction(function Function, functionType FunctionType) {
 	enclosingFunction := r.currentFunction
+	if function.isGenerator && functionType != INITIALIZER {
+		functionType = GENERATOR
+	}
 	r.currentFunction = functionType
 	r.beginScope()
 	for _, param := range function.params {
-		r.declare(param)
-		r.define(param)
+		r.declare(param.name)
+		if param.defaultValue != nil {
+			r.resolveExpression(param.defaultValue)
+		}
+		r.define(param.name)
 	}
 	r.resolveStatements(function.body)
 	r.endScope()
@@ -137,13 +263,36 @@ func (r *Resolver) visitVar(stmt Var) {
 	r.define(stmt.name)
 }
 
+func (r *Resolver) visitVarDestructure(stmt VarDestructure) {
+	for _, variable := range stmt.target.variables() {
+		r.declare(variable.name)
+	}
+	r.resolveExpression(stmt.initializer)
+	for _, variable := range stmt.target.variables() {
+		r.define(variable.name)
+	}
+}
+
 func (r *Resolver) resolveExpression(expr Expr) {
 	expr.accept(r)
 }
 
+func (r *Resolver) resolveExpressions(exprs []Expr) {
+	for _, expr := range exprs {
+		r.resolveExpression(expr)
+	}
+}
+
 func (r *Resolver) visitAssign(expr Assign) {
 	r.resolveExpression(expr.value)
-	r.resolveLocal(expr, expr.name)
+	r.resolveLocal(expr.id, expr.name)
+}
+
+func (r *Resolver) visitAssignDestructure(expr AssignDestructure) {
+	r.resolveExpression(expr.value)
+	for _, variable := range expr.target.variables() {
+		r.resolveLocal(variable.id, variable.name)
+	}
 }
 
 func (r *Resolver) visitBinary(expr Binary) {
@@ -156,16 +305,65 @@ func (r *Resolver) visitCall(expr Call) {
 	for _, argument := range expr.arguments {
 		r.resolveExpression(argument)
 	}
+	for _, argument := range expr.namedArguments {
+		r.resolveExpression(argument.value)
+	}
 }
 
 func (r *Resolver) visitGet(expr Get) {
 	r.resolveExpression(expr.object)
+	r.checkPrivateAccess(expr.name, expr.classId)
+	variable, ok := expr.object.(Variable)
+	if !ok {
+		return
+	}
+	enum, ok := r.lookUpEnum(variable.name)
+	if ok && expr.name.lexeme != "values" && !slices.ContainsFunc(enum.members, func(member Token) bool { return member.lexeme == expr.name.lexeme }) {
+		r.lx.ResolveError(expr.name, fmt.Sprintf("Enum '%s' has no member '%s'.", enum.name.lexeme, expr.name.lexeme))
+	}
+}
+
+// lookUpEnum finds the enum declaration name refers to, if it refers to one.
+// enums has an extra map at the front for globals.
+func (r *Resolver) lookUpEnum(name Token) (Enum, bool) {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if _, ok := r.scopes[i][name.lexeme]; ok {
+			enum, ok := r.enums[i+1][name.lexeme]
+			return enum, ok
+		}
+	}
+	enum, ok := r.enums[0][name.lexeme]
+	return enum, ok
 }
 
 func (r *Resolver) visitGrouping(expr Grouping) {
 	r.resolveExpression(expr.expression)
 }
 
+func (r *Resolver) visitIndex(expr Index) {
+	r.resolveExpression(expr.object)
+	r.resolveExpression(expr.index)
+}
+
+func (r *Resolver) visitList(expr List) {
+	for _, element := range expr.elements {
+		r.resolveExpression(element)
+	}
+}
+
+// The variable of a comprehension is scoped to it, like the one of a for-in loop.
+func (r *Resolver) visitComprehension(expr Comprehension) {
+	r.resolveExpression(expr.iterable)
+	r.beginScope()
+	r.declare(expr.name)
+	r.define(expr.name)
+	if expr.condition != nil {
+		r.resolveExpression(expr.condition)
+	}
+	r.resolveExpression(expr.element)
+	r.endScope()
+}
+
 func (r *Resolver) visitLiteral(expr Literal) {}
 
 func (r *Resolver) visitLogical(expr Logical) {
@@ -173,9 +371,31 @@ func (r *Resolver) visitLogical(expr Logical) {
 	r.resolveExpression(expr.right)
 }
 
+func (r *Resolver) visitOptionalChain(expr OptionalChain) {
+	r.resolveExpression(expr.expression)
+}
+
+func (r *Resolver) visitRange(expr Range) {
+	r.resolveExpression(expr.start)
+	r.resolveExpression(expr.end)
+}
+
+func (r *Resolver) visitSpread(expr Spread) {
+	r.resolveExpression(expr.expression)
+}
+
 func (r *Resolver) visitSet(expr Set) {
 	r.resolveExpression(expr.value)
 	r.resolveExpression(expr.object)
+	r.checkPrivateAccess(expr.name, expr.classId)
+}
+
+// checkPrivateAccess rejects private member names used outside of a class body.
+// Which class may access them is only known at runtime.
+func (r *Resolver) checkPrivateAccess(name Token, classId int) {
+	if name.tokenType == PRIVATE_IDENTIFIER && classId == 0 {
+		r.lx.ResolveError(name, fmt.Sprintf("Can't access private member '%s' outside of a class.", name.lexeme))
+	}
 }
 
 func (r *Resolver) visitSuper(expr Super) {
@@ -185,7 +405,7 @@ func (r *Resolver) visitSuper(expr Super) {
 	case YESCLASS:
 		r.lx.ResolveError(expr.keyword, "Can't use 'super' in a class with no superclass.")
 	}
-	r.resolveLocal(expr, expr.keyword)
+	r.resolveLocal(expr.id, expr.keyword)
 }
 
 func (r *Resolver) visitThis(expr This) {
@@ -193,7 +413,7 @@ func (r *Resolver) visitThis(expr This) {
 		r.lx.ResolveError(expr.keyword, "Can't use 'this' outside of a class.")
 		return
 	}
-	r.resolveLocal(expr, expr.keyword)
+	r.resolveLocal(expr.id, expr.keyword)
 }
 
 func (r *Resolver) visitUnary(expr Unary) {
@@ -207,18 +427,22 @@ func (r *Resolver) visitVariable(expr Variable) {
 	if val, ok := r.scopes[len(r.scopes)-1][expr.name.lexeme]; (ok == true) && (val == false) {
 		r.lx.ResolveError(expr.name, "Can't read local variable in its own initializer.")
 	}
-	r.resolveLocal(expr, expr.name)
+	r.resolveLocal(expr.id, expr.name)
 }
 
 func (r *Resolver) beginScope() {
 	r.scopes = append(r.scopes, make(map[string]bool))
+	r.enums = append(r.enums, make(map[string]Enum))
 }
 
 func (r *Resolver) endScope() {
 	r.scopes = r.scopes[:len(r.scopes)-1]
+	r.enums = r.enums[:len(r.enums)-1]
 }
 
 func (r *Resolver) declare(name Token) {
+	// Redeclaring an enum's name hides the enum
+	delete(r.enums[len(r.scopes)], name.lexeme)
 	if len(r.scopes) == 0 {
 		return
 	}
@@ -236,10 +460,10 @@ func (r *Resolver) define(name Token) {
 	r.scopes[len(r.scopes)-1][name.lexeme] = true
 }
 
-func (r *Resolver) resolveLocal(expr Expr, name Token) {
+func (r *Resolver) resolveLocal(id int, name Token) {
 	for i := len(r.scopes) - 1; i >= 0; i-- {
 		if _, ok := r.scopes[i][name.lexeme]; ok {
-			r.interp.resolve(expr, len(r.scopes)-1-i)
+			r.interp.resolve(id, len(r.scopes)-1-i)
 			return
 		}
 	}
@@ -1,24 +1,40 @@
 package main
 
-import "strconv"
+import (
+	"math/big"
+	"strconv"
+	"strings"
+)
 
 var keywords = map[string]TokenType{
 	"and": AND,
+	"assert": ASSERT,
+	"case": CASE,
 	"class": CLASS,
+	"default": DEFAULT,
+	"defer": DEFER,
 	"else": ELSE,
+	"enum": ENUM,
 	"false": FALSE,
 	"for": FOR,
 	"fun": FUN,
 	"if": IF,
+	"implements": IMPLEMENTS,
+	"in": IN,
+	"interface": INTERFACE,
+	"is": IS,
+	"match": MATCH,
 	"nil": NIL,
 	"or": OR,
 	"print": PRINT,
 	"return": RETURN,
+	"spawn": SPAWN,
 	"super": SUPER,
 	"this": THIS,
 	"true": TRUE,
 	"var": VAR,
 	"while": WHILE,
+	"yield": YIELD,
 }
 
 type Scanner struct {
@@ -53,10 +69,26 @@ func (sc *Scanner) scanToken() {
 		sc.addShortToken(LEFT_BRACE)
 	case '}':
 		sc.addShortToken(RIGHT_BRACE)
+	case '[':
+		sc.addShortToken(LEFT_BRACKET)
+	case ']':
+		sc.addShortToken(RIGHT_BRACKET)
+	case ':':
+		sc.addShortToken(COLON)
 	case ',':
 		sc.addShortToken(COMMA)
 	case '.':
-		sc.addShortToken(DOT)
+		if sc.peek() == '.' && sc.peekNext() == '.' {
+			sc.current += 2
+			sc.addShortToken(ELLIPSIS)
+		} else if sc.peek() == '.' && sc.peekNext() == '<' {
+			sc.current += 2
+			sc.addShortToken(DOT_DOT_LESS)
+		} else if sc.match('.') {
+			sc.addShortToken(DOT_DOT)
+		} else {
+			sc.addShortToken(DOT)
+		}
 	case '-':
 		sc.addShortToken(MINUS)
 	case '+':
@@ -65,6 +97,8 @@ func (sc *Scanner) scanToken() {
 		sc.addShortToken(SEMICOLON)
 	case '*':
 		sc.addShortToken(STAR)
+	case '@':
+		sc.addShortToken(AT)
 	case '!':
 		if sc.match('=') {
 			sc.addShortToken(BANG_EQUAL)
@@ -74,6 +108,8 @@ func (sc *Scanner) scanToken() {
 	case '=':
 		if sc.match('=') {
 			sc.addShortToken(EQUAL_EQUAL)
+		} else if sc.match('>') {
+			sc.addShortToken(ARROW)
 		} else {
 			sc.addShortToken(EQUAL)
 		}
@@ -89,6 +125,20 @@ func (sc *Scanner) scanToken() {
 		} else {
 			sc.addShortToken(GREATER)
 		}
+	case '|':
+		if sc.match('>') {
+			sc.addShortToken(PIPE)
+		} else {
+			sc.lox.Error(sc.line, "Unexpected character.")
+		}
+	case '?':
+		if sc.match('.') {
+			sc.addShortToken(QUESTION_DOT)
+		} else if sc.match('?') {
+			sc.addShortToken(QUESTION_QUESTION)
+		} else {
+			sc.lox.Error(sc.line, "Unexpected character.")
+		}
 	case '/':
 		if sc.match('/') {
 			// A comment goes until the end of the string
@@ -107,6 +157,15 @@ func (sc *Scanner) scanToken() {
 		sc.line += 1
 	case '"':
 		sc.string()
+	case '#':
+		if isAlpha(sc.peek()) {
+			for isAlphaNumeric(sc.peek()) {
+				sc.advance()
+			}
+			sc.addShortToken(PRIVATE_IDENTIFIER)
+		} else {
+			sc.lox.Error(sc.line, "Unexpected character.")
+		}
 	default:
 		if isDigit(c) {
 			sc.number()
@@ -144,8 +203,16 @@ func (sc *Scanner) number() {
 		}
 	}
 
-	num, _ := strconv.ParseFloat(sc.source[sc.start:sc.current], 64)
-	sc.addToken(NUMBER, num)
+	text := sc.source[sc.start:sc.current]
+	if strings.Contains(text, ".") {
+		num, _ := strconv.ParseFloat(text, 64)
+		sc.addToken(NUMBER, num)
+	} else if num, err := strconv.ParseInt(text, 10, 64); err == nil {
+		sc.addToken(NUMBER, num)
+	} else {
+		num, _ := new(big.Int).SetString(text, 10)
+		sc.addToken(NUMBER, num)
+	}
 }
 
 func (sc *Scanner) string() {
@@ -220,5 +287,5 @@ func (sc *Scanner) addShortToken(tokenType TokenType) {
 
 func (sc *Scanner) addToken(tokenType TokenType, literal any) {
 	text := sc.source[sc.start:sc.current]
-	sc.tokens = append(sc.tokens, Token{tokenType: tokenType, lexeme: text, literal: literal, line: sc.line})
+	sc.tokens = append(sc.tokens, Token{tokenType: tokenType, lexeme: text, literal: literal, line: sc.line, offset: sc.start})
 }
@@ -4,6 +4,17 @@ type Stmt interface {
 	accept(StmtVisitor)
 }
 
+// Assert keeps the source text of its condition for the failure message.
+type Assert struct {
+	keyword   Token
+	condition Expr
+	message   Expr
+	source    string
+	id        int
+}
+
+func (a Assert) accept(v StmtVisitor) { v.visitAssert(a) }
+
 type Block struct {
 	statments []Stmt
 	id        int
@@ -11,15 +22,53 @@ type Block struct {
 
 func (b Block) accept(v StmtVisitor) { v.visitBlock(b) }
 
+// Decorators are applied bottom up, so the one closest to the declaration is
+// applied first.
 type Class struct {
 	name       Token
 	superclass Variable
+	interfaces []Variable
+	fields     []Field
 	methods    []Function
+	decorators []Expr
 	id         int
 }
 
 func (c Class) accept(v StmtVisitor) { v.visitClass(c) }
 
+// Field declarations only describe the types of fields for the type checker.
+type Field struct {
+	name Token
+	typ  TypeAnnotation
+}
+
+// Defer runs a call when the enclosing function returns. The callee and
+// arguments are evaluated at the defer statement.
+type Defer struct {
+	keyword Token
+	call    Call
+	id      int
+}
+
+func (d Defer) accept(v StmtVisitor) { v.visitDefer(d) }
+
+type Enum struct {
+	name    Token
+	members []Token
+	id      int
+}
+
+func (e Enum) accept(v StmtVisitor) { v.visitEnum(e) }
+
+// Methods of an interface are abstract functions.
+type Interface struct {
+	name    Token
+	methods []Function
+	id      int
+}
+
+func (i Interface) accept(v StmtVisitor) { v.visitInterface(i) }
+
 type Expression struct {
 	expr Expr
 	id   int
@@ -27,15 +76,48 @@ type Expression struct {
 
 func (e Expression) accept(v StmtVisitor) { v.visitExpression(e) }
 
+type ForIn struct {
+	keyword  Token
+	name     Token
+	iterable Expr
+	body     Stmt
+	id       int
+}
+
+func (f ForIn) accept(v StmtVisitor) { v.visitForIn(f) }
+
+// An abstract function is a method declared without a body.
 type Function struct {
-	name   Token
-	params []Token
-	body   []Stmt
-	id     int
+	name        Token
+	params      []Param
+	returnType  TypeAnnotation
+	body        []Stmt
+	isGenerator bool
+	isAbstract  bool
+	decorators  []Expr
+	id          int
 }
 
 func (f Function) accept(v StmtVisitor) { v.visitFunction(f) }
 
+// The type of a rest parameter is the type of its elements.
+type Param struct {
+	name         Token
+	typ          TypeAnnotation
+	defaultValue Expr
+	variadic     bool
+}
+
+// A TypeAnnotation is the optional `: type` after a variable, parameter, field
+// or parameter list. Leaving it out keeps the value dynamically typed.
+type TypeAnnotation struct {
+	name Token
+}
+
+func (ta TypeAnnotation) isPresent() bool {
+	return ta.name.lexeme != ""
+}
+
 type If struct {
 	condition  Expr
 	thenBranch Stmt
@@ -45,9 +127,25 @@ type If struct {
 
 func (i If) accept(v StmtVisitor) { v.visitIf(i) }
 
+type Match struct {
+	keyword Token
+	subject Expr
+	cases   []MatchCase
+	id      int
+}
+
+func (m Match) accept(v StmtVisitor) { v.visitMatch(m) }
+
+type MatchCase struct {
+	patterns []Pattern
+	guard    Expr
+	body     Stmt
+}
+
 type Print struct {
-	expr Expr
-	id   int
+	keyword Token
+	expr    Expr
+	id      int
 }
 
 func (p Print) accept(v StmtVisitor) { v.visitPrint(p) }
@@ -60,14 +158,56 @@ type Return struct {
 
 func (r Return) accept(v StmtVisitor) { v.visitReturn(r) }
 
+type Spawn struct {
+	keyword Token
+	call    Call
+	id      int
+}
+
+func (s Spawn) accept(v StmtVisitor) { v.visitSpawn(s) }
+
 type Var struct {
 	name        Token
+	typ         TypeAnnotation
 	initializer Expr
 	id          int
 }
 
 func (variable Var) accept(v StmtVisitor) { v.visitVar(variable) }
 
+type VarDestructure struct {
+	target      DestructuringTarget
+	initializer Expr
+	id          int
+}
+
+func (vd VarDestructure) accept(v StmtVisitor) { v.visitVarDestructure(vd) }
+
+// A DestructuringTarget is either a list pattern `[a, b, ...rest]`, which
+// takes elements by position, or an object pattern `{x, y: b}`, which takes
+// properties by key.
+type DestructuringTarget struct {
+	open     Token
+	elements []DestructuringElement
+	rest     Variable
+}
+
+func (dt DestructuringTarget) variables() []Variable {
+	output := make([]Variable, 0)
+	for _, element := range dt.elements {
+		output = append(output, element.target)
+	}
+	if dt.rest.id > 0 {
+		output = append(output, dt.rest)
+	}
+	return output
+}
+
+type DestructuringElement struct {
+	key    Token
+	target Variable
+}
+
 type While struct {
 	condition Expr
 	body      Stmt
@@ -76,14 +216,31 @@ type While struct {
 
 func (w While) accept(v StmtVisitor) { v.visitWhile(w) }
 
+type Yield struct {
+	keyword Token
+	value   Expr
+	id      int
+}
+
+func (y Yield) accept(v StmtVisitor) { v.visitYield(y) }
+
 type StmtVisitor interface {
+	visitAssert(Assert)
 	visitBlock(Block)
 	visitClass(Class)
+	visitDefer(Defer)
+	visitEnum(Enum)
 	visitExpression(Expression)
+	visitForIn(ForIn)
 	visitFunction(Function)
 	visitIf(If)
+	visitInterface(Interface)
+	visitMatch(Match)
 	visitPrint(Print)
 	visitReturn(Return)
+	visitSpawn(Spawn)
 	visitVar(Var)
+	visitVarDestructure(VarDestructure)
 	visitWhile(While)
+	visitYield(Yield)
 }
@@ -5,4 +5,5 @@ type Token struct {
 	lexeme string
 	literal any
 	line int
+	offset int
 }
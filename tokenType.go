@@ -7,13 +7,18 @@ const (
 	RIGHT_PAREN
 	LEFT_BRACE
 	RIGHT_BRACE
+	LEFT_BRACKET
+	RIGHT_BRACKET
+	COLON
 	COMMA
 	DOT
+	ELLIPSIS
 	MINUS
 	PLUS
 	SEMICOLON
 	SLASH
 	STAR
+	AT
 
 	// One or two character tokens
 	BANG
@@ -24,29 +29,48 @@ const (
 	GREATER_EQUAL
 	LESS
 	LESS_EQUAL
+	QUESTION_DOT
+	QUESTION_QUESTION
+	ARROW
+	PIPE
+	DOT_DOT
+	DOT_DOT_LESS
 
 	// Literals
 	IDENTIFIER
+	PRIVATE_IDENTIFIER
 	STRING
 	NUMBER
 
 	// Keywords
 	AND
+	ASSERT
+	CASE
 	CLASS
+	DEFAULT
+	DEFER
 	ELSE
+	ENUM
 	FALSE
 	FUN
 	FOR
 	IF
+	IMPLEMENTS
+	IN
+	INTERFACE
+	IS
+	MATCH
 	NIL
 	OR
 	PRINT
 	RETURN
+	SPAWN
 	SUPER
 	THIS
 	TRUE
 	VAR
 	WHILE
+	YIELD
 
 	EOF
 )
@@ -0,0 +1,691 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// The TypeChecker runs after the Resolver and reports operations that would
+// fail at runtime because of the types involved. Only annotations and literals
+// give values a type, so unannotated code stays dynamically typed.
+type TypeChecker struct {
+	scopes       []map[string]staticType
+	classes      []map[string]*classType
+	currentClass *classType
+	returnTypes  []staticType
+	output       staticType
+	lx           *Lox
+}
+
+// A staticType is what the checker knows about a value. Instances are named
+// after their class and point to its classType, and the zero value is the dynamic type any, which is
+// compatible with everything. Operators are only checked when an operand's type
+// comes from an annotation, so that unannotated code like `1 + nil` still only
+// fails at runtime.
+type staticType struct {
+	name      string
+	class     *classType
+	signature *signature
+	annotated bool
+}
+
+var (
+	anyType    = staticType{}
+	numberType = staticType{name: "number"}
+	stringType = staticType{name: "string"}
+	boolType   = staticType{name: "bool"}
+	nilType    = staticType{name: "nil"}
+	listType   = staticType{name: "list"}
+)
+
+// Values of these types can't have methods, so operators on them never dispatch
+// to an overload.
+var builtinTypes = []string{"number", "string", "bool", "nil", "list", "range", "function", "class"}
+
+func (st staticType) isAny() bool {
+	return st.name == ""
+}
+
+func (st staticType) isBuiltin() bool {
+	return slices.Contains(builtinTypes, st.name)
+}
+
+func (st staticType) String() string {
+	if st.isAny() {
+		return "any"
+	}
+	return st.name
+}
+
+// A signature describes what calling a function or class expects and returns.
+type signature struct {
+	params  []Param
+	types   []staticType
+	returns staticType
+}
+
+// A classType describes a class, an interface or an enum by name.
+type classType struct {
+	name       string
+	superclass *classType
+	interfaces []*classType
+	fields     map[string]staticType
+	methods    map[string]*signature
+}
+
+func (ct *classType) findField(name string) (staticType, bool) {
+	for klass := ct; klass != nil; klass = klass.superclass {
+		if typ, ok := klass.fields[name]; ok {
+			return typ, true
+		}
+	}
+	return anyType, false
+}
+
+func (ct *classType) findMethod(name string) (*signature, bool) {
+	for klass := ct; klass != nil; klass = klass.superclass {
+		if sig, ok := klass.methods[name]; ok {
+			return sig, true
+		}
+	}
+	return nil, false
+}
+
+func (ct *classType) isSubtypeOf(other *classType) bool {
+	for klass := ct; klass != nil; klass = klass.superclass {
+		if klass == other || slices.Contains(klass.interfaces, other) {
+			return true
+		}
+	}
+	return false
+}
+
+func newTypeChecker(lx *Lox) *TypeChecker {
+	globals := map[string]staticType{
+		"clock": {name: "function", signature: &signature{returns: numberType}},
+	}
+	return &TypeChecker{scopes: []map[string]staticType{globals}, classes: []map[string]*classType{make(map[string]*classType)}, lx: lx}
+}
+
+func (tc *TypeChecker) checkStatements(statements []Stmt) {
+	tc.checkBlock(statements)
+}
+
+// checkBlock declares the classes, interfaces and enums of the block first, so
+// annotations can refer to them before their declaration.
+func (tc *TypeChecker) checkBlock(statements []Stmt) {
+	for _, statement := range statements {
+		switch stmt := statement.(type) {
+		case Class:
+			tc.declareClass(stmt.name.lexeme)
+		case Interface:
+			tc.declareClass(stmt.name.lexeme)
+		case Enum:
+			tc.declareClass(stmt.name.lexeme)
+		}
+	}
+	for _, statement := range statements {
+		if statement != nil {
+			statement.accept(tc)
+		}
+	}
+}
+
+func (tc *TypeChecker) check(expr Expr) staticType {
+	tc.output = anyType
+	expr.accept(tc)
+	output := tc.output
+	tc.output = anyType
+	return output
+}
+
+// --------------- TYPES ---------------
+
+func (tc *TypeChecker) resolveType(annotation TypeAnnotation) staticType {
+	if !annotation.isPresent() {
+		return anyType
+	}
+	name := annotation.name.lexeme
+	if name == "any" {
+		return anyType
+	}
+	if slices.Contains(builtinTypes, name) {
+		return staticType{name: name, annotated: true}
+	}
+	if class, ok := tc.lookUpClass(name); ok {
+		return staticType{name: name, class: class, annotated: true}
+	}
+	tc.lx.TypeError(annotation.name, fmt.Sprintf("Unknown type '%s'.", name))
+	return anyType
+}
+
+func (tc *TypeChecker) isAssignable(from staticType, to staticType) bool {
+	if from.isAny() || to.isAny() {
+		return true
+	}
+	if from.class != nil && to.class != nil {
+		return from.class.isSubtypeOf(to.class)
+	}
+	if from.name == to.name {
+		return true
+	}
+	if from.name == "nil" {
+		// Anything but the primitive types may be missing
+		return to.name != "number" && to.name != "string" && to.name != "bool"
+	}
+	return false
+}
+
+func (tc *TypeChecker) expectAssignable(token Token, from staticType, to staticType) {
+	if !tc.isAssignable(from, to) {
+		tc.lx.TypeError(token, fmt.Sprintf("Type '%s' is not assignable to '%s'.", from, to))
+	}
+}
+
+func (tc *TypeChecker) signatureOf(function Function) *signature {
+	types := make([]staticType, 0)
+	for _, param := range function.params {
+		types = append(types, tc.resolveType(param.typ))
+	}
+	return &signature{params: function.params, types: types, returns: tc.resolveType(function.returnType)}
+}
+
+func (tc *TypeChecker) checkCallArguments(sig *signature, expr Call) {
+	spread := false
+	for i, argument := range expr.arguments {
+		typ := tc.check(argument)
+		// Arguments after a spread can't be matched to parameters
+		if _, ok := argument.(Spread); ok {
+			spread = true
+		}
+		if len(sig.params) == 0 || spread {
+			continue
+		}
+		index := min(i, len(sig.params)-1)
+		if index < i && !sig.params[index].variadic {
+			continue
+		}
+		tc.expectAssignable(expr.paren, typ, sig.types[index])
+	}
+	for _, argument := range expr.namedArguments {
+		typ := tc.check(argument.value)
+		for i, param := range sig.params {
+			if param.name.lexeme == argument.name.lexeme {
+				tc.expectAssignable(argument.name, typ, sig.types[i])
+			}
+		}
+	}
+}
+
+// --------------- SCOPES ---------------
+
+// Class types are scoped like variables, so that a class declared in a function
+// doesn't replace another class with the same name.
+func (tc *TypeChecker) beginScope() {
+	tc.scopes = append(tc.scopes, make(map[string]staticType))
+	tc.classes = append(tc.classes, make(map[string]*classType))
+}
+
+func (tc *TypeChecker) endScope() {
+	tc.scopes = tc.scopes[:len(tc.scopes)-1]
+	tc.classes = tc.classes[:len(tc.classes)-1]
+}
+
+// declareClass returns the class type called name in the innermost scope,
+// creating it if it hasn't been declared yet.
+func (tc *TypeChecker) declareClass(name string) *classType {
+	scope := tc.classes[len(tc.classes)-1]
+	class, ok := scope[name]
+	if !ok {
+		class = &classType{name: name}
+		scope[name] = class
+	}
+	return class
+}
+
+func (tc *TypeChecker) lookUpClass(name string) (*classType, bool) {
+	for i := len(tc.classes) - 1; i >= 0; i-- {
+		if class, ok := tc.classes[i][name]; ok {
+			return class, true
+		}
+	}
+	return nil, false
+}
+
+func (tc *TypeChecker) declare(name string, typ staticType) {
+	tc.scopes[len(tc.scopes)-1][name] = typ
+}
+
+func (tc *TypeChecker) lookUp(name string) staticType {
+	for i := len(tc.scopes) - 1; i >= 0; i-- {
+		if typ, ok := tc.scopes[i][name]; ok {
+			return typ
+		}
+	}
+	return anyType
+}
+
+// --------------- STATEMENTS ---------------
+
+func (tc *TypeChecker) visitAssert(stmt Assert) {
+	tc.check(stmt.condition)
+	if stmt.message != nil {
+		tc.check(stmt.message)
+	}
+}
+
+func (tc *TypeChecker) visitBlock(stmt Block) {
+	tc.beginScope()
+	tc.checkBlock(stmt.statments)
+	tc.endScope()
+}
+
+func (tc *TypeChecker) visitClass(stmt Class) {
+	class := tc.declareClass(stmt.name.lexeme)
+	tc.checkDecorators(stmt.decorators)
+	class.fields = make(map[string]staticType)
+	class.methods = make(map[string]*signature)
+	if stmt.superclass.id > 0 {
+		tc.check(stmt.superclass)
+		class.superclass, _ = tc.lookUpClass(stmt.superclass.name.lexeme)
+	}
+	for _, iface := range stmt.interfaces {
+		if ifaceClass, ok := tc.lookUpClass(iface.name.lexeme); ok {
+			class.interfaces = append(class.interfaces, ifaceClass)
+		}
+	}
+	for _, field := range stmt.fields {
+		class.fields[field.name.lexeme] = tc.resolveType(field.typ)
+	}
+	instance := staticType{name: class.name, class: class}
+	signatures := make([]*signature, len(stmt.methods))
+	for i, method := range stmt.methods {
+		tc.checkDecorators(method.decorators)
+		signatures[i] = tc.signatureOf(method)
+		if method.name.lexeme == "init" {
+			signatures[i].returns = instance
+		}
+		// A decorated method is called through whatever the decorator returned
+		if len(method.decorators) > 0 {
+			class.methods[method.name.lexeme] = nil
+		} else {
+			class.methods[method.name.lexeme] = signatures[i]
+		}
+	}
+	constructor, ok := class.findMethod("init")
+	if !ok {
+		constructor = &signature{returns: instance}
+	}
+	if len(stmt.decorators) > 0 || constructor == nil {
+		tc.declare(stmt.name.lexeme, anyType)
+	} else {
+		tc.declare(stmt.name.lexeme, staticType{name: "class", signature: &signature{params: constructor.params, types: constructor.types, returns: instance}})
+	}
+	enclosingClass := tc.currentClass
+	tc.currentClass = class
+	for i, method := range stmt.methods {
+		if !method.isAbstract {
+			tc.checkFunction(method, signatures[i])
+		}
+	}
+	tc.currentClass = enclosingClass
+}
+
+func (tc *TypeChecker) visitDefer(stmt Defer) {
+	tc.check(stmt.call)
+}
+
+func (tc *TypeChecker) visitEnum(stmt Enum) {
+	tc.declareClass(stmt.name.lexeme)
+	tc.declare(stmt.name.lexeme, anyType)
+}
+
+func (tc *TypeChecker) visitInterface(stmt Interface) {
+	iface := tc.declareClass(stmt.name.lexeme)
+	iface.methods = make(map[string]*signature)
+	for _, method := range stmt.methods {
+		iface.methods[method.name.lexeme] = tc.signatureOf(method)
+	}
+	tc.declare(stmt.name.lexeme, anyType)
+}
+
+func (tc *TypeChecker) visitExpression(stmt Expression) {
+	tc.check(stmt.expr)
+}
+
+func (tc *TypeChecker) visitForIn(stmt ForIn) {
+	tc.check(stmt.iterable)
+	tc.beginScope()
+	tc.declare(stmt.name.lexeme, anyType)
+	stmt.body.accept(tc)
+	tc.endScope()
+}
+
+func (tc *TypeChecker) visitFunction(stmt Function) {
+	tc.checkDecorators(stmt.decorators)
+	sig := tc.signatureOf(stmt)
+	if len(stmt.decorators) > 0 {
+		tc.declare(stmt.name.lexeme, anyType)
+	} else {
+		tc.declare(stmt.name.lexeme, staticType{name: "function", signature: sig})
+	}
+	tc.checkFunction(stmt, sig)
+}
+
+// checkDecorators checks the decorator expressions. Decorators can replace what
+// they decorate with anything, so decorated declarations are left untyped.
+func (tc *TypeChecker) checkDecorators(decorators []Expr) {
+	for _, decorator := range decorators {
+		tc.check(decorator)
+	}
+}
+
+func (tc *TypeChecker) checkFunction(function Function, sig *signature) {
+	tc.beginScope()
+	if tc.currentClass != nil {
+		tc.declare("this", staticType{name: tc.currentClass.name, class: tc.currentClass})
+	}
+	for i, param := range function.params {
+		if param.defaultValue != nil {
+			tc.expectAssignable(param.name, tc.check(param.defaultValue), sig.types[i])
+		}
+		if param.variadic {
+			tc.declare(param.name.lexeme, listType)
+		} else {
+			tc.declare(param.name.lexeme, sig.types[i])
+		}
+	}
+	returnType := sig.returns
+	if function.isGenerator {
+		returnType = anyType
+	}
+	tc.returnTypes = append(tc.returnTypes, returnType)
+	tc.checkBlock(function.body)
+	tc.returnTypes = tc.returnTypes[:len(tc.returnTypes)-1]
+	tc.endScope()
+}
+
+func (tc *TypeChecker) visitIf(stmt If) {
+	tc.check(stmt.condition)
+	stmt.thenBranch.accept(tc)
+	if stmt.elseBranch != nil {
+		stmt.elseBranch.accept(tc)
+	}
+}
+
+func (tc *TypeChecker) visitMatch(stmt Match) {
+	tc.check(stmt.subject)
+	for _, matchCase := range stmt.cases {
+		tc.beginScope()
+		for _, pattern := range matchCase.patterns {
+			for _, binding := range pattern.bindings() {
+				tc.declare(binding.lexeme, anyType)
+			}
+		}
+		if matchCase.guard != nil {
+			tc.check(matchCase.guard)
+		}
+		matchCase.body.accept(tc)
+		tc.endScope()
+	}
+}
+
+func (tc *TypeChecker) visitPrint(stmt Print) {
+	tc.check(stmt.expr)
+}
+
+func (tc *TypeChecker) visitReturn(stmt Return) {
+	typ := nilType
+	if stmt.value != nil {
+		typ = tc.check(stmt.value)
+	}
+	if len(tc.returnTypes) == 0 {
+		return
+	}
+	returnType := tc.returnTypes[len(tc.returnTypes)-1]
+	if !tc.isAssignable(typ, returnType) {
+		tc.lx.TypeError(stmt.keyword, fmt.Sprintf("Can't return '%s' from a function returning '%s'.", typ, returnType))
+	}
+}
+
+func (tc *TypeChecker) visitSpawn(stmt Spawn) {
+	tc.check(stmt.call)
+}
+
+func (tc *TypeChecker) visitVar(stmt Var) {
+	typ := tc.resolveType(stmt.typ)
+	if stmt.initializer != nil {
+		tc.expectAssignable(stmt.name, tc.check(stmt.initializer), typ)
+	}
+	tc.declare(stmt.name.lexeme, typ)
+}
+
+func (tc *TypeChecker) visitVarDestructure(stmt VarDestructure) {
+	tc.check(stmt.initializer)
+	for _, variable := range stmt.target.variables() {
+		tc.declare(variable.name.lexeme, anyType)
+	}
+}
+
+func (tc *TypeChecker) visitWhile(stmt While) {
+	tc.check(stmt.condition)
+	stmt.body.accept(tc)
+}
+
+func (tc *TypeChecker) visitYield(stmt Yield) {
+	if stmt.value != nil {
+		tc.check(stmt.value)
+	}
+}
+
+// --------------- EXPRESSIONS ---------------
+
+func (tc *TypeChecker) visitAssign(expr Assign) {
+	typ := tc.check(expr.value)
+	tc.expectAssignable(expr.name, typ, tc.lookUp(expr.name.lexeme))
+	tc.output = typ
+}
+
+func (tc *TypeChecker) visitAssignDestructure(expr AssignDestructure) {
+	tc.check(expr.value)
+}
+
+func (tc *TypeChecker) visitBinary(expr Binary) {
+	left := tc.check(expr.left)
+	right := tc.check(expr.right)
+	// Instances may overload any operator
+	known := left.isBuiltin() && right.isBuiltin()
+	annotated := left.annotated || right.annotated
+	switch expr.operator.tokenType {
+	case EQUAL_EQUAL, BANG_EQUAL, IS:
+		tc.output = boolType
+	case GREATER, GREATER_EQUAL, LESS, LESS_EQUAL:
+		tc.expectNumbers(expr.operator, left, right)
+		if known {
+			tc.output = staticType{name: "bool", annotated: annotated}
+		}
+	case MINUS, SLASH, STAR:
+		tc.expectNumbers(expr.operator, left, right)
+		if known {
+			tc.output = staticType{name: "number", annotated: annotated}
+		}
+	case PLUS:
+		if known && left.name == "number" && right.name == "number" {
+			tc.output = staticType{name: "number", annotated: annotated}
+		} else if known && left.name == "string" && right.name == "string" {
+			tc.output = staticType{name: "string", annotated: annotated}
+		} else if known && annotated {
+			tc.lx.TypeError(expr.operator, "Operands must be two numbers or two strings.")
+		}
+	}
+}
+
+func (tc *TypeChecker) expectNumbers(operator Token, left staticType, right staticType) {
+	if !left.annotated && !right.annotated {
+		return
+	}
+	if left.isBuiltin() && left.name != "number" || right.isBuiltin() && right.name != "number" {
+		tc.lx.TypeError(operator, "Operands must be numbers.")
+	}
+}
+
+func (tc *TypeChecker) visitCall(expr Call) {
+	callee := tc.check(expr.callee)
+	if callee.annotated && callee.isBuiltin() && callee.name != "function" && callee.name != "class" {
+		tc.lx.TypeError(expr.paren, "Can only call functions and classes.")
+	}
+	if callee.signature == nil {
+		for _, argument := range expr.arguments {
+			tc.check(argument)
+		}
+		for _, argument := range expr.namedArguments {
+			tc.check(argument.value)
+		}
+		return
+	}
+	tc.checkCallArguments(callee.signature, expr)
+	tc.output = callee.signature.returns
+}
+
+func (tc *TypeChecker) visitGet(expr Get) {
+	object := tc.check(expr.object)
+	if expr.optional {
+		return
+	}
+	if object.name == "string" {
+		if expr.name.lexeme == "length" {
+			tc.output = staticType{name: "number", annotated: object.annotated}
+		} else if _, ok := stringMethods[expr.name.lexeme]; !ok && object.annotated {
+			tc.lx.TypeError(expr.name, fmt.Sprintf("Undefined property '%s'.", expr.name.lexeme))
+		}
+		return
+	}
+	class := object.class
+	if class == nil {
+		return
+	}
+	if typ, ok := class.findField(expr.name.lexeme); ok {
+		tc.output = typ
+	} else if sig, ok := class.findMethod(expr.name.lexeme); ok {
+		tc.output = staticType{name: "function", signature: sig}
+	}
+}
+
+func (tc *TypeChecker) visitGrouping(expr Grouping) {
+	tc.output = tc.check(expr.expression)
+}
+
+func (tc *TypeChecker) visitIndex(expr Index) {
+	object := tc.check(expr.object)
+	index := tc.check(expr.index)
+	if index.annotated && index.isBuiltin() && index.name != "number" && index.name != "range" {
+		tc.lx.TypeError(expr.bracket, "Index must be an integer.")
+	}
+	if object.annotated && object.isBuiltin() && object.name != "string" && object.name != "list" {
+		tc.lx.TypeError(expr.bracket, "Can only index lists and strings.")
+	}
+	// Slicing with a range gives a sequence of the same type
+	if object.name == "string" || index.name == "range" && object.name == "list" {
+		tc.output = object
+	}
+}
+
+func (tc *TypeChecker) visitList(expr List) {
+	for _, element := range expr.elements {
+		tc.check(element)
+	}
+	tc.output = listType
+}
+
+func (tc *TypeChecker) visitComprehension(expr Comprehension) {
+	tc.check(expr.iterable)
+	tc.beginScope()
+	tc.declare(expr.name.lexeme, anyType)
+	if expr.condition != nil {
+		tc.check(expr.condition)
+	}
+	tc.check(expr.element)
+	tc.endScope()
+	tc.output = listType
+}
+
+func (tc *TypeChecker) visitLiteral(expr Literal) {
+	switch expr.value.kind {
+	case INT_KIND, FLOAT_KIND, BIG_INT_KIND:
+		tc.output = numberType
+	case STRING_KIND:
+		tc.output = stringType
+	case BOOL_KIND:
+		tc.output = boolType
+	case NIL_KIND:
+		tc.output = nilType
+	}
+}
+
+func (tc *TypeChecker) visitLogical(expr Logical) {
+	left := tc.check(expr.left)
+	right := tc.check(expr.right)
+	if left.name == right.name && left.class == right.class {
+		tc.output = staticType{name: left.name, class: left.class, annotated: left.annotated || right.annotated}
+	}
+}
+
+func (tc *TypeChecker) visitOptionalChain(expr OptionalChain) {
+	tc.check(expr.expression)
+}
+
+func (tc *TypeChecker) visitSet(expr Set) {
+	object := tc.check(expr.object)
+	value := tc.check(expr.value)
+	if class := object.class; class != nil {
+		if typ, ok := class.findField(expr.name.lexeme); ok {
+			tc.expectAssignable(expr.name, value, typ)
+		}
+	}
+	tc.output = value
+}
+
+func (tc *TypeChecker) visitRange(expr Range) {
+	for _, bound := range []staticType{tc.check(expr.start), tc.check(expr.end)} {
+		if bound.annotated && bound.isBuiltin() && bound.name != "number" {
+			tc.lx.TypeError(expr.operator, "Range bounds must be integers.")
+		}
+	}
+	tc.output = staticType{name: "range"}
+}
+
+func (tc *TypeChecker) visitSpread(expr Spread) {
+	tc.check(expr.expression)
+}
+
+func (tc *TypeChecker) visitSuper(expr Super) {
+	if tc.currentClass == nil || tc.currentClass.superclass == nil {
+		return
+	}
+	if sig, ok := tc.currentClass.superclass.findMethod(expr.method.lexeme); ok {
+		tc.output = staticType{name: "function", signature: sig}
+	}
+}
+
+func (tc *TypeChecker) visitThis(expr This) {
+	tc.output = tc.lookUp("this")
+}
+
+func (tc *TypeChecker) visitUnary(expr Unary) {
+	right := tc.check(expr.right)
+	switch expr.operator.tokenType {
+	case BANG:
+		tc.output = boolType
+	case MINUS:
+		if right.annotated && right.isBuiltin() && right.name != "number" {
+			tc.lx.TypeError(expr.operator, "Operand must be a number.")
+		}
+		if right.name == "number" {
+			tc.output = right
+		}
+	}
+}
+
+func (tc *TypeChecker) visitVariable(expr Variable) {
+	tc.output = tc.lookUp(expr.name.lexeme)
+}
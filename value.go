@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+type ValueKind uint8
+
+const (
+	NIL_KIND ValueKind = iota
+	BOOL_KIND
+	INT_KIND
+	FLOAT_KIND
+	BIG_INT_KIND
+	STRING_KIND
+	OBJECT_KIND
+)
+
+// Value is the representation of every Lox value. Nil, booleans, integers and
+// floats are stored inline in bits so that they never allocate. Big integers,
+// strings and objects such as instances, functions and lists are held in ref.
+// The zero Value is nil.
+type Value struct {
+	kind ValueKind
+	bits uint64
+	ref  any
+}
+
+func boolValue(b bool) Value {
+	if b {
+		return Value{kind: BOOL_KIND, bits: 1}
+	}
+	return Value{kind: BOOL_KIND}
+}
+
+func intValue(i int64) Value {
+	return Value{kind: INT_KIND, bits: uint64(i)}
+}
+
+func floatValue(f float64) Value {
+	return Value{kind: FLOAT_KIND, bits: math.Float64bits(f)}
+}
+
+// bigValue demotes i to an inline integer when it fits.
+func bigValue(i *big.Int) Value {
+	if i.IsInt64() {
+		return intValue(i.Int64())
+	}
+	return Value{kind: BIG_INT_KIND, ref: i}
+}
+
+func stringValue(s string) Value {
+	return Value{kind: STRING_KIND, ref: s}
+}
+
+func objectValue(object any) Value {
+	return Value{kind: OBJECT_KIND, ref: object}
+}
+
+// valueOf converts the Go representation of a literal into a Value.
+func valueOf(literal any) Value {
+	switch l := literal.(type) {
+	case nil:
+		return Value{}
+	case bool:
+		return boolValue(l)
+	case int64:
+		return intValue(l)
+	case float64:
+		return floatValue(l)
+	case *big.Int:
+		return bigValue(l)
+	case string:
+		return stringValue(l)
+	}
+	return objectValue(literal)
+}
+
+func (v Value) isNil() bool {
+	return v.kind == NIL_KIND
+}
+
+func (v Value) isNumber() bool {
+	return v.kind == INT_KIND || v.kind == FLOAT_KIND || v.kind == BIG_INT_KIND
+}
+
+func (v Value) isString() bool {
+	return v.kind == STRING_KIND
+}
+
+func (v Value) asBool() bool {
+	return v.bits != 0
+}
+
+func (v Value) asInt() int64 {
+	return int64(v.bits)
+}
+
+func (v Value) asFloat() float64 {
+	return math.Float64frombits(v.bits)
+}
+
+func (v Value) asBigInt() *big.Int {
+	return v.ref.(*big.Int)
+}
+
+func (v Value) asString() string {
+	s, _ := v.ref.(string)
+	return s
+}
+
+func isTruthy(v Value) bool {
+	switch v.kind {
+	case NIL_KIND:
+		return false
+	case BOOL_KIND:
+		return v.asBool()
+	default:
+		return true
+	}
+}
+
+// isEqual compares numbers by value and strings by content. Everything else,
+// including instances, classes and functions, is compared by identity.
+func isEqual(left Value, right Value) bool {
+	if left.kind == right.kind {
+		switch left.kind {
+		case NIL_KIND:
+			return true
+		case BOOL_KIND, INT_KIND:
+			return left.bits == right.bits
+		case STRING_KIND:
+			return left.asString() == right.asString()
+		}
+	}
+	if left.isNumber() && right.isNumber() {
+		cmp, ordered := compareNumbers(left, right)
+		return ordered && cmp == 0
+	}
+	if left.kind != OBJECT_KIND || right.kind != OBJECT_KIND {
+		return false
+	}
+	switch l := left.ref.(type) {
+	case LoxInstance:
+		r, ok := right.ref.(LoxInstance)
+		return ok && reflect.ValueOf(l.fields).Pointer() == reflect.ValueOf(r.fields).Pointer()
+	case LoxClass:
+		r, ok := right.ref.(LoxClass)
+		return ok && reflect.ValueOf(l.methods).Pointer() == reflect.ValueOf(r.methods).Pointer()
+	case LoxFunction:
+		r, ok := right.ref.(LoxFunction)
+		return ok && sameFunction(l, r)
+	case unboundMethod:
+		r, ok := right.ref.(unboundMethod)
+		return ok && sameFunction(l.method, r.method)
+	}
+	leftType := reflect.TypeOf(left.ref)
+	if leftType != reflect.TypeOf(right.ref) || !leftType.Comparable() {
+		return false
+	}
+	return left.ref == right.ref
+}
+
+// String is the one textual form of a value. print only differs from it for
+// instances with a toString method and for lists containing them.
+func (v Value) String() string {
+	switch v.kind {
+	case NIL_KIND:
+		return "nil"
+	case BOOL_KIND:
+		return strconv.FormatBool(v.asBool())
+	case INT_KIND:
+		return strconv.FormatInt(v.asInt(), 10)
+	case FLOAT_KIND:
+		return strconv.FormatFloat(v.asFloat(), 'g', -1, 64)
+	case STRING_KIND:
+		return v.asString()
+	}
+	return fmt.Sprint(v.ref)
+}